@@ -40,6 +40,11 @@ func WithAssertionFailure(err error) error {
 
 // HasAssertionFailure returns true if the error or any of its causes
 // is an assertion failure annotation.
+//
+// The predicate sees through regular wrappers but is blocked by
+// barriers (see errors.Handled()): an assertion failure masked behind
+// a barrier is not reported, matching the intent of barriers at API
+// boundaries.
 func HasAssertionFailure(err error) bool {
 	_, ok := markers.If(err, func(err error) (v interface{}, ok bool) {
 		v, ok = err.(*withAssertionFailure)
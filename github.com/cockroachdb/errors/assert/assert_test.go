@@ -0,0 +1,47 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package assert_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/errors/assert"
+	"github.com/cockroachdb/errors/barriers"
+	"github.com/cockroachdb/errors/testutils"
+)
+
+// TestHasAssertionFailure checks the two sides of the predicate:
+// the annotation is detected through regular wrappers, but a barrier
+// blocks the detection, since the masked cause is outside the visible
+// chain.
+func TestHasAssertionFailure(t *testing.T) {
+	tt := testutils.T{T: t}
+
+	baseErr := errors.New("hello")
+	tt.Check(!assert.HasAssertionFailure(baseErr))
+
+	err := assert.WithAssertionFailure(baseErr)
+	tt.Check(assert.HasAssertionFailure(err))
+
+	// The annotation remains visible through regular wrappers.
+	wrapped := fmt.Errorf("wide: %w", err)
+	tt.Check(assert.HasAssertionFailure(wrapped))
+
+	// A barrier hides the cause chain and blocks the detection.
+	masked := barriers.Handled(err)
+	tt.Check(!assert.HasAssertionFailure(masked))
+}
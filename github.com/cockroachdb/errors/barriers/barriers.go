@@ -19,6 +19,8 @@ import (
 	"fmt"
 
 	"github.com/cockroachdb/errors/errbase"
+	"github.com/cockroachdb/errors/safedetails"
+	"github.com/cockroachdb/errors/withstack"
 	"github.com/gogo/protobuf/proto"
 )
 
@@ -49,13 +51,53 @@ func HandledWithMessage(err error, msg string) error {
 	return &barrierError{maskedErr: err, msg: msg}
 }
 
-// HandledWithMessagef is like HandledWithMessagef except the message
+// HandledWithMessagef is like HandledWithMessage except the message
 // is formatted.
+//
+// The format and arguments are also recorded through the safe-details
+// machinery: arguments wrapped with safedetails.Safe() remain visible
+// in Sentry reports, the others are redacted.
 func HandledWithMessagef(err error, format string, args ...interface{}) error {
 	if err == nil {
 		return nil
 	}
-	return &barrierError{maskedErr: err, msg: fmt.Sprintf(format, args...)}
+	b := &barrierError{maskedErr: err, msg: fmt.Sprintf(format, args...)}
+	return safedetails.WithSafeDetails(b, format, args...)
+}
+
+// HandledWithSafeDetails is like Handled, except that the stack trace
+// of the masked error (if any) is retained in the barrier's safe
+// details. This way the barrier still reports a meaningful stack
+// trace via GetReportableStackTrace() / Sentry reports, even though
+// the masked message and cause chain remain inaccessible to
+// Cause()/Unwrap() and thus Is().
+func HandledWithSafeDetails(err error) error {
+	if err == nil {
+		return nil
+	}
+	b := &barrierError{maskedErr: err, msg: err.Error()}
+	if detail, ok := maskedStackDetail(err); ok {
+		// The marker makes the retention explicit: stack extraction only
+		// picks up a stack payload from a barrier's details when it was
+		// put there on purpose.
+		b.stackDetail = withstack.RetainStackDetail(detail)
+	}
+	return b
+}
+
+// maskedStackDetail retrieves the flattened stack trace payload of
+// the outermost stack capture in the given error chain, if any.
+func maskedStackDetail(err error) (string, bool) {
+	for c := err; c != nil; c = errbase.UnwrapOnce(c) {
+		if _, ok := c.(errbase.StackTraceProvider); ok {
+			if sd, ok := c.(errbase.SafeDetailer); ok {
+				if details := sd.SafeDetails(); len(details) > 0 && details[0] != "" {
+					return details[0], true
+				}
+			}
+		}
+	}
+	return "", false
 }
 
 // barrierError is a leaf error type. It encapsulates a chain of
@@ -71,6 +113,10 @@ type barrierError struct {
 	msg string
 	// Masked error chain.
 	maskedErr error
+	// stackDetail, if non-empty, is the flattened stack trace of the
+	// masked error, retained so that the barrier still reports a
+	// stack. See HandledWithSafeDetails().
+	stackDetail string
 }
 
 var _ error = (*barrierError)(nil)
@@ -84,6 +130,11 @@ func (e *barrierError) Error() string { return e.msg }
 // SafeDetails reports the PII-free details from the masked error.
 func (e *barrierError) SafeDetails() []string {
 	var details []string
+	if e.stackDetail != "" {
+		// The stack payload comes first, where the stack trace
+		// extraction looks for it.
+		details = append(details, e.stackDetail)
+	}
 	for err := e.maskedErr; err != nil; err = errbase.UnwrapOnce(err) {
 		sd := errbase.GetSafeDetails(err)
 		details = sd.Fill(details)
@@ -112,9 +163,24 @@ func encodeBarrier(
 }
 
 // A barrier error is decoded exactly.
-func decodeBarrier(ctx context.Context, msg string, _ []string, payload proto.Message) error {
-	enc := payload.(*errbase.EncodedError)
-	return &barrierError{msg: msg, maskedErr: errbase.DecodeError(ctx, *enc)}
+func decodeBarrier(ctx context.Context, msg string, details []string, payload proto.Message) error {
+	enc, ok := payload.(*errbase.EncodedError)
+	if !ok {
+		// The masked payload is missing - for example dropped by
+		// EncodeErrorLimited on the other side - or has an unexpected
+		// type. Give up and let DecodeError use the opaque type, which
+		// preserves the message and the safe details.
+		return nil
+	}
+	b := &barrierError{msg: msg, maskedErr: errbase.DecodeError(ctx, *enc)}
+	if len(details) > 0 {
+		if _, ok := withstack.RetainedStackDetail(details[0]); ok {
+			// The barrier was created with HandledWithSafeDetails():
+			// restore the retained stack payload, marker included.
+			b.stackDetail = details[0]
+		}
+	}
+	return b
 }
 
 func init() {
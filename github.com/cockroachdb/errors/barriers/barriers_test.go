@@ -0,0 +1,116 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package barriers_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/errors/barriers"
+	"github.com/cockroachdb/errors/errbase"
+	"github.com/cockroachdb/errors/testutils"
+	"github.com/cockroachdb/errors/withstack"
+)
+
+// TestBarrierMasksStackTrace checks that a plain barrier does not
+// leak the stack trace of the error it masks, either locally or after
+// an encode/decode cycle: stack retention requires the explicit
+// HandledWithSafeDetails() variant.
+func TestBarrierMasksStackTrace(t *testing.T) {
+	tt := testutils.T{T: t}
+	ctx := context.Background()
+
+	stackErr := withstack.WithStack(errors.New("hello"))
+
+	b := barriers.Handled(stackErr)
+	tt.Check(withstack.GetReportableStackTrace(b) == nil)
+
+	dec := errbase.DecodeError(ctx, errbase.EncodeError(ctx, b))
+	tt.Check(withstack.GetReportableStackTrace(dec) == nil)
+}
+
+// TestBarrierRetainsStackTrace checks that HandledWithSafeDetails()
+// keeps the masked error's stack trace reportable, including across
+// an encode/decode cycle, while still hiding the cause chain.
+func TestBarrierRetainsStackTrace(t *testing.T) {
+	tt := testutils.T{T: t}
+	ctx := context.Background()
+
+	stackErr := withstack.WithStack(errors.New("hello"))
+
+	b := barriers.HandledWithSafeDetails(stackErr)
+	tt.Assert(withstack.GetReportableStackTrace(b) != nil)
+	// The cause remains masked regardless.
+	tt.Check(errbase.UnwrapOnce(b) == nil)
+
+	dec := errbase.DecodeError(ctx, errbase.EncodeError(ctx, b))
+	tt.Assert(withstack.GetReportableStackTrace(dec) != nil)
+	tt.CheckEqual(dec.Error(), b.Error())
+}
+
+// TestHandledManyRetainsStackTrace checks that HandledMany() retains
+// the stack trace of the first masked error, like
+// HandledWithSafeDetails() does, including across an encode/decode
+// cycle.
+func TestHandledManyRetainsStackTrace(t *testing.T) {
+	tt := testutils.T{T: t}
+	ctx := context.Background()
+
+	stackErr := withstack.WithStack(errors.New("hello"))
+	otherErr := errors.New("world")
+
+	b := barriers.HandledMany([]error{stackErr, otherErr}, "woo")
+	tt.Assert(chainStack(b) != nil)
+
+	dec := errbase.DecodeError(ctx, errbase.EncodeError(ctx, b))
+	tt.Assert(chainStack(dec) != nil)
+	tt.CheckEqual(dec.Error(), "woo")
+}
+
+// TestIsBarrierAfterDecode checks that barrier detection survives
+// the network: IsBarrier() compares type keys, so a decoded barrier
+// is still recognized even when it hides inside a wrapper.
+func TestIsBarrierAfterDecode(t *testing.T) {
+	tt := testutils.T{T: t}
+	ctx := context.Background()
+
+	b := barriers.Handled(errors.New("hello"))
+	tt.Assert(barriers.IsBarrier(b))
+
+	dec := errbase.DecodeError(ctx, errbase.EncodeError(ctx, b))
+	tt.Check(barriers.IsBarrier(dec))
+
+	// Detection also works through wrappers added after decoding.
+	wrapped := fmt.Errorf("wide: %w", dec)
+	tt.Check(barriers.IsBarrier(wrapped))
+
+	// A non-barrier error round-tripped the same way stays negative.
+	dec = errbase.DecodeError(ctx, errbase.EncodeError(ctx, errors.New("hello")))
+	tt.Check(!barriers.IsBarrier(dec))
+}
+
+// chainStack extracts the first stack trace found in the error's
+// chain of causes, the way report builders walk an error level by
+// level.
+func chainStack(err error) *withstack.ReportableStackTrace {
+	for c := err; c != nil; c = errbase.UnwrapOnce(c) {
+		if st := withstack.GetReportableStackTrace(c); st != nil {
+			return st
+		}
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package barriers
+
+import "github.com/cockroachdb/errors/errbase"
+
+// barrierTypeKey identifies the barrier error type for IsBarrier().
+var barrierTypeKey = errbase.GetTypeKey((*barrierError)(nil))
+
+// IsBarrier returns true if any level in the error's chain of causes
+// is a barrier, i.e. went through Handled() or one of its variants.
+//
+// The detection compares error type keys, not concrete Go types, so
+// it also recognizes barriers that have traveled through the network
+// (encoded with EncodeError and decoded with DecodeError).
+func IsBarrier(err error) bool {
+	for c := err; c != nil; c = errbase.UnwrapOnce(c) {
+		if errbase.GetTypeKey(c) == barrierTypeKey {
+			return true
+		}
+	}
+	return false
+}
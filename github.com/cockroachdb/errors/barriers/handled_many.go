@@ -0,0 +1,69 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package barriers
+
+import (
+	"github.com/cockroachdb/errors/secondary"
+	"github.com/cockroachdb/errors/withstack"
+)
+
+// HandledMany masks several independent errors behind one opaque
+// error with the given message, e.g. to present a single error at an
+// API boundary after aggregating the failures of multiple attempts.
+//
+// Like Handled, none of the masked errors remains reachable via
+// Cause()/Unwrap(), and thus Is(); however, all of them remain
+// preserved for reporting: their stack traces and safe details are
+// carried through, and a report builder that walks the resulting
+// error (e.g. BuildSentryReport) produces one section per masked
+// error.
+//
+// Ordering: the first error in the slice becomes the barrier's masked
+// error, at the innermost level of the result; each subsequent error
+// is attached, in slice order, as a hidden secondary payload further
+// out. Report builders that render levels from the innermost outwards
+// therefore list the masked errors in slice order, and the stack
+// retained on the barrier is that of the first error in the slice.
+//
+// Nil entries in the slice are ignored. The result is nil when the
+// slice contains no non-nil error.
+func HandledMany(errs []error, msg string) error {
+	var remaining []error
+	for _, e := range errs {
+		if e != nil {
+			remaining = append(remaining, e)
+		}
+	}
+	if len(remaining) == 0 {
+		return nil
+	}
+
+	b := &barrierError{maskedErr: remaining[0], msg: msg}
+	if detail, ok := maskedStackDetail(remaining[0]); ok {
+		// Mark the retention so that the stack extraction picks the
+		// payload up; see HandledWithSafeDetails().
+		b.stackDetail = withstack.RetainStackDetail(detail)
+	}
+
+	var res error = b
+	for _, e := range remaining[1:] {
+		// The secondary payload does not participate in cause analysis,
+		// so the masked errors stay unreachable to Is()/Unwrap(); mask
+		// them anyway so that even code that inspects secondary
+		// payloads directly sees a barrier.
+		res = secondary.WithSecondaryError(res, Handled(e))
+	}
+	return res
+}
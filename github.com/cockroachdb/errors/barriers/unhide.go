@@ -0,0 +1,39 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package barriers
+
+import "github.com/cockroachdb/errors/errbase"
+
+// UnhideForReporting returns the error masked by the first barrier
+// found in err's chain of causes, for consumption by reporting code
+// only — e.g. to extract the hidden cause's stack trace with
+// withstack.GetReportableStackTrace() so that crash reports are not
+// blank behind an API boundary.
+//
+// The barrier semantics are not otherwise affected: the masked error
+// remains invisible to Unwrap()/Cause() and thus to the Is()
+// facility. Callers must take care not to propagate the returned
+// error outside of the reporting path, as doing so would defeat the
+// purpose of the barrier.
+//
+// The function returns nil when err contains no barrier.
+func UnhideForReporting(err error) error {
+	for c := err; c != nil; c = errbase.UnwrapOnce(c) {
+		if b, ok := c.(*barrierError); ok {
+			return b.maskedErr
+		}
+	}
+	return nil
+}
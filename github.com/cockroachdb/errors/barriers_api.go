@@ -21,3 +21,8 @@ func Handled(err error) error { return barriers.Handled(err) }
 
 // HandledWithMessage forwards a definition.
 func HandledWithMessage(err error, msg string) error { return barriers.HandledWithMessage(err, msg) }
+
+// HandledWithMessagef forwards a definition.
+func HandledWithMessagef(err error, format string, args ...interface{}) error {
+	return barriers.HandledWithMessagef(err, format, args...)
+}
@@ -49,7 +49,10 @@ func WithContextTags(err error, ctx context.Context) error {
 }
 
 // GetContextTags retrieves the k/v pairs stored in the error.
-// The sets are returned from outermost to innermost level of cause.
+// The sets are returned from outermost to innermost level of cause,
+// one logtags.Buffer per WithContextTags() annotation, without
+// merging, so a caller can tell which layer contributed which tags.
+// An error without any annotation yields a nil slice.
 // The returned logtags.Buffer only know about the string
 // representation of the values originally captured by the error.
 func GetContextTags(err error) (res []*logtags.Buffer) {
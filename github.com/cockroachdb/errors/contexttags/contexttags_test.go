@@ -0,0 +1,49 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package contexttags_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/errors/contexttags"
+	"github.com/cockroachdb/errors/testutils"
+	"github.com/cockroachdb/logtags"
+)
+
+// TestTwoTagSetsAtDifferentLevels checks that tag sets attached at
+// different wrap levels remain separate buffers, returned from
+// outermost to innermost, without merging.
+func TestTwoTagSetsAtDifferentLevels(t *testing.T) {
+	tt := testutils.T{T: t}
+
+	innerCtx := logtags.AddTag(context.Background(), "n", 1)
+	outerCtx := logtags.AddTag(context.Background(), "req", "abc")
+
+	err := contexttags.WithContextTags(errors.New("hello"), innerCtx)
+	err = fmt.Errorf("wide: %w", err)
+	err = contexttags.WithContextTags(err, outerCtx)
+
+	bufs := contexttags.GetContextTags(err)
+	tt.AssertEqual(len(bufs), 2)
+	// Outermost annotation first.
+	tt.CheckEqual(bufs[0].String(), "req=abc")
+	tt.CheckEqual(bufs[1].String(), "n1")
+
+	// An error without annotations yields nothing.
+	tt.CheckEqual(len(contexttags.GetContextTags(errors.New("hello"))), 0)
+}
@@ -0,0 +1,54 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package contexttags
+
+// The standardized context tag keys under which HTTP middleware is
+// expected to stash the request information on the context, so that
+// WithContextTags() captures them on errors for later reporting.
+const (
+	// HTTPMethodTagKey is the context tag key for the HTTP request
+	// method.
+	HTTPMethodTagKey = "http.method"
+	// HTTPPathTagKey is the context tag key for the HTTP request
+	// path.
+	HTTPPathTagKey = "http.path"
+)
+
+// GetHTTPRequestContext retrieves the HTTP request method and path
+// attached to the error as context tags under the standard keys
+// above (see WithContextTags). This standardizes how middleware
+// stashes request information on errors so that it can be reported
+// alongside the stack trace.
+//
+// The tag sets are searched from outermost to innermost level of
+// cause; the first set containing both keys wins. The boolean return
+// is false when no such tags are present.
+func GetHTTPRequestContext(err error) (method, path string, ok bool) {
+	for _, b := range GetContextTags(err) {
+		var haveMethod, havePath bool
+		for _, t := range b.Get() {
+			switch t.Key() {
+			case HTTPMethodTagKey:
+				method, haveMethod = t.ValueStr(), true
+			case HTTPPathTagKey:
+				path, havePath = t.ValueStr(), true
+			}
+		}
+		if haveMethod && havePath {
+			return method, path, true
+		}
+	}
+	return "", "", false
+}
@@ -22,6 +22,7 @@ import (
 
 	"github.com/cockroachdb/errors/barriers"
 	"github.com/cockroachdb/errors/errbase"
+	"github.com/cockroachdb/errors/withstack"
 )
 
 // Domain is the type of a domain annotation.
@@ -31,23 +32,56 @@ type Domain string
 // from a barrier.
 const NoDomain Domain = "error domain: <none>"
 
-// GetDomain extracts the domain of the given error, or NoDomain if
-// the error's cause does not have a domain annotation.
+// GetDomain extracts the effective domain of the given error.
+//
+// The precedence is as follows:
+// - the outermost explicitly-assigned domain (via WithDomain() or one
+//   of the constructors above) wins;
+// - otherwise, the domain is inferred from the package where the
+//   leaf error captured its stack trace, when one is available;
+// - otherwise, the result is NoDomain.
+//
+// A barrier resets the domain: the annotations and stack traces of
+// the masked cause are unreachable, so the effective domain is that
+// of the barrier itself (or NoDomain if it has none).
 func GetDomain(err error) Domain {
-	for {
-		if b, ok := err.(*withDomain); ok {
+	for c := err; c != nil; c = errbase.UnwrapOnce(c) {
+		if b, ok := c.(*withDomain); ok {
 			return b.domain
 		}
-		// Recurse to the cause.
-		if c := errbase.UnwrapOnce(err); c != nil {
-			err = c
-			continue
+	}
+	// No explicit annotation anywhere: infer the domain from the
+	// innermost stack capture, mirroring what New() would have
+	// assigned at the same place.
+	if st := innermostStack(err); st != nil && len(st.Frames) > 0 {
+		// The frames are ordered oldest first; the capture site is the
+		// newest frame. Use the absolute path: the package directory
+		// must match what PackageDomainAtDepth() derives from
+		// runtime.Caller at the same site.
+		f := st.Frames[len(st.Frames)-1]
+		file := f.AbsPath
+		if file == "" {
+			file = f.Filename
+		}
+		if dir := filepath.Dir(file); dir != "." && dir != "" {
+			return Domain("error domain: pkg " + dir)
 		}
-		break
 	}
 	return NoDomain
 }
 
+// innermostStack retrieves the stack trace attached at the innermost
+// level of err's chain of causes that carries one, if any.
+func innermostStack(err error) *withstack.ReportableStackTrace {
+	var st *withstack.ReportableStackTrace
+	for c := err; c != nil; c = errbase.UnwrapOnce(c) {
+		if s := withstack.GetReportableStackTrace(c); s != nil {
+			st = s
+		}
+	}
+	return st
+}
+
 // WithDomain wraps an error so that it appears to come from the given domain.
 //
 // Domain is shown:
@@ -100,6 +134,24 @@ func Handled(err error) error {
 
 // Handledf with format and args can be implemented similarly.
 
+// IsInDomain returns true if any level in the error's chain of causes
+// carries the given domain. This matches domains assigned explicitly
+// with WithDomain() as well as those inferred from the originating
+// package (New() and Handled() above attach the package domain the
+// same way).
+//
+// Barriers block domain visibility: the domains of an error masked
+// behind Handled()/HandledInDomain() are not reachable, only the
+// domain (if any) assigned to the barrier itself.
+func IsInDomain(err error, d Domain) bool {
+	for ; err != nil; err = errbase.UnwrapOnce(err) {
+		if b, ok := err.(*withDomain); ok && b.domain == d {
+			return true
+		}
+	}
+	return false
+}
+
 // NotInDomain returns true if and only if the error's
 // domain is not one of the specified domains.
 func NotInDomain(err error, domains ...Domain) bool {
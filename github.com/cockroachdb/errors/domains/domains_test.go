@@ -0,0 +1,43 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package domains
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cockroachdb/errors/testutils"
+	"github.com/cockroachdb/errors/withstack"
+)
+
+// TestGetDomainInfersLeafPackage checks the package-domain inference
+// in GetDomain: an error without an explicit domain annotation but
+// with a captured stack reports the package domain of the capture
+// site, just as New() would have assigned there.
+func TestGetDomainInfersLeafPackage(t *testing.T) {
+	tt := testutils.T{T: t}
+
+	origErr := withstack.WithStack(errors.New("hello"))
+	// The stack was captured in this file, so the inferred domain must
+	// match the one runtime.Caller computes here.
+	tt.CheckEqual(GetDomain(origErr), PackageDomainAtDepth(0))
+
+	// An explicit annotation takes precedence over the inference.
+	werr := WithDomain(origErr, NamedDomain("here"))
+	tt.CheckEqual(GetDomain(werr), NamedDomain("here"))
+
+	// Without a stack nor an annotation, there is no domain.
+	tt.CheckEqual(GetDomain(errors.New("hello")), NoDomain)
+}
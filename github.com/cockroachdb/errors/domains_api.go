@@ -34,6 +34,9 @@ func PackageDomainAtDepth(depth int) Domain { return domains.PackageDomainAtDept
 // WithDomain forwards a definition.
 func WithDomain(err error, domain Domain) error { return domains.WithDomain(err, domain) }
 
+// IsInDomain forwards a definition.
+func IsInDomain(err error, d Domain) bool { return domains.IsInDomain(err, d) }
+
 // NotInDomain forwards a definition.
 func NotInDomain(err error, doms ...Domain) bool { return domains.NotInDomain(err, doms...) }
 
@@ -23,11 +23,25 @@ import (
 )
 
 // DecodeError decodes an error.
+//
+// Wrapper types without a registered decoder (e.g. produced by a
+// newer peer) do not degrade to a leaf: they decode to an opaque
+// wrapper that preserves the message prefix, the decoded cause and
+// the raw encoded details. Cause analysis (Is() etc.) thus still
+// traverses into the cause, and re-encoding the error restores the
+// original payload exactly, so forwarding it to a third party that
+// does know the type loses nothing.
+// Payloads stamped with a newer wire version than this build
+// understands (see EncodeErrorAtVersion) are decoded on a best-effort
+// basis and the result carries a hint noting the version mismatch.
 func DecodeError(ctx context.Context, enc EncodedError) error {
+	var err error
 	if w := enc.GetWrapper(); w != nil {
-		return decodeWrapper(ctx, w)
+		err = decodeWrapper(ctx, w)
+	} else {
+		err = decodeLeaf(ctx, enc.GetLeaf())
 	}
-	return decodeLeaf(ctx, enc.GetLeaf())
+	return decodeWireVersionNotice(enc, err)
 }
 
 func decodeLeaf(ctx context.Context, enc *errorspb.EncodedErrorLeaf) error {
@@ -47,7 +61,7 @@ func decodeLeaf(ctx context.Context, enc *errorspb.EncodedErrorLeaf) error {
 
 	// Do we have a leaf decoder for this type?
 	typeKey := TypeKey(enc.Details.ErrorTypeMark.FamilyName)
-	if decoder, ok := leafDecoders[typeKey]; ok {
+	if decoder, ok := lookupLeafDecoder(typeKey); ok {
 		// Yes, use it.
 		genErr := decoder(ctx, enc.Message, enc.Details.ReportablePayload, payload)
 		if genErr != nil {
@@ -93,7 +107,7 @@ func decodeWrapper(ctx context.Context, enc *errorspb.EncodedWrapper) error {
 
 	// Do we have a wrapper decoder for this?
 	typeKey := TypeKey(enc.Details.ErrorTypeMark.FamilyName)
-	if decoder, ok := decoders[typeKey]; ok {
+	if decoder, ok := lookupWrapperDecoder(typeKey); ok {
 		// Yes, use it.
 		genErr := decoder(ctx, cause, enc.MessagePrefix, enc.Details.ReportablePayload, payload)
 		if genErr != nil {
@@ -125,6 +139,7 @@ func RegisterLeafDecoder(theType TypeKey, decoder LeafDecoder) {
 	} else {
 		leafDecoders[theType] = decoder
 	}
+	leafDecoderCache.invalidate()
 }
 
 // LeafDecoder is to be provided (via RegisterLeafDecoder above)
@@ -149,6 +164,7 @@ func RegisterWrapperDecoder(theType TypeKey, decoder WrapperDecoder) {
 	} else {
 		decoders[theType] = decoder
 	}
+	wrapperDecoderCache.invalidate()
 }
 
 // WrapperDecoder is to be provided (via RegisterWrapperDecoder above)
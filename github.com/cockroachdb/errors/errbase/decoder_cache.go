@@ -0,0 +1,90 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package errbase
+
+import "sync"
+
+// decoderCacheMaxEntries bounds the number of memoized decoder
+// lookups. A process decodes a bounded set of error types in
+// practice, so the bound is rarely reached; when it is, the cache is
+// simply flushed and rebuilt, which keeps the implementation simple
+// and the memory use bounded without LRU bookkeeping.
+const decoderCacheMaxEntries = 128
+
+// decoderCache memoizes decoder registry lookups keyed by the encoded
+// type name, so that repeated decoding of the same error type avoids
+// re-resolving the decoder. It is safe for concurrent use by multiple
+// decoding goroutines.
+//
+// Only successful lookups are cached: a type without a registered
+// decoder takes the opaque decoding path anyway, and not caching the
+// absence means a decoder registered later is picked up immediately.
+// The cache is flushed whenever a decoder is (re-)registered or
+// unregistered, so runtime registration is always respected.
+type decoderCache struct {
+	mu      sync.RWMutex
+	entries map[TypeKey]interface{}
+}
+
+var leafDecoderCache decoderCache
+var wrapperDecoderCache decoderCache
+
+func (c *decoderCache) get(key TypeKey) (interface{}, bool) {
+	c.mu.RLock()
+	v, ok := c.entries[key]
+	c.mu.RUnlock()
+	return v, ok
+}
+
+func (c *decoderCache) put(key TypeKey, v interface{}) {
+	c.mu.Lock()
+	if c.entries == nil || len(c.entries) >= decoderCacheMaxEntries {
+		c.entries = make(map[TypeKey]interface{})
+	}
+	c.entries[key] = v
+	c.mu.Unlock()
+}
+
+func (c *decoderCache) invalidate() {
+	c.mu.Lock()
+	c.entries = nil
+	c.mu.Unlock()
+}
+
+// lookupLeafDecoder resolves the leaf decoder for the given type key,
+// through the cache.
+func lookupLeafDecoder(key TypeKey) (LeafDecoder, bool) {
+	if v, ok := leafDecoderCache.get(key); ok {
+		return v.(LeafDecoder), true
+	}
+	d, ok := leafDecoders[key]
+	if ok {
+		leafDecoderCache.put(key, d)
+	}
+	return d, ok
+}
+
+// lookupWrapperDecoder resolves the wrapper decoder for the given
+// type key, through the cache.
+func lookupWrapperDecoder(key TypeKey) (WrapperDecoder, bool) {
+	if v, ok := wrapperDecoderCache.get(key); ok {
+		return v.(WrapperDecoder), true
+	}
+	d, ok := decoders[key]
+	if ok {
+		wrapperDecoderCache.put(key, d)
+	}
+	return d, ok
+}
@@ -29,7 +29,19 @@ import (
 type EncodedError = errorspb.EncodedError
 
 // EncodeError encodes an error.
+//
+// The outermost level of the result is stamped with
+// EncodedErrorWireVersion. See EncodeErrorAtVersion() to produce an
+// encoding suitable for a known-old peer.
 func EncodeError(ctx context.Context, err error) EncodedError {
+	enc := encodeError(ctx, err)
+	stampWireVersion(&enc, EncodedErrorWireVersion)
+	return enc
+}
+
+// encodeError implements the encoding recursion. The version stamp is
+// not applied here: it only belongs on the outermost level.
+func encodeError(ctx context.Context, err error) EncodedError {
 	if cause := UnwrapOnce(err); cause != nil {
 		return encodeWrapper(ctx, err, cause)
 	}
@@ -148,7 +160,7 @@ func encodeWrapper(ctx context.Context, err, cause error) EncodedError {
 	return EncodedError{
 		Error: &errorspb.EncodedError_Wrapper{
 			Wrapper: &errorspb.EncodedWrapper{
-				Cause:         EncodeError(ctx, cause),
+				Cause:         encodeError(ctx, cause),
 				MessagePrefix: msg,
 				Details:       details,
 			},
@@ -265,6 +277,19 @@ func GetTypeMark(err error) errorspb.ErrorTypeMark {
 // Go type when an error is encoded. Wrappers that have not been
 // registered will be encoded using the opaqueLeaf type.
 //
+// The encoder can attach an arbitrary structured payload, either as
+// PII-free strings in safeDetails (e.g. one "key=value" entry per
+// field) or as a proto message in payload; the matching decoder
+// registered with RegisterLeafDecoder() receives both verbatim and
+// can reconstruct the original struct exactly. For example, a domain
+// type like
+//
+//	type QuotaExceeded struct { Limit, Used int64 }
+//
+// round-trips through EncodeError/DecodeError with both fields intact
+// when its encoder emits the two integers in safeDetails and its
+// decoder parses them back.
+//
 // Note: if the error type has been migrated from a previous location
 // or a different type, ensure that RegisterTypeMigration() was called
 // prior to RegisterLeafEncoder().
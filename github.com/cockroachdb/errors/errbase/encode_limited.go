@@ -0,0 +1,156 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package errbase
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/cockroachdb/errors/errorspb"
+)
+
+// TruncatedPayloadMarker is appended as a safe detail string to an
+// encoded error whose payload was reduced by EncodeErrorLimited, so
+// that the receiver can tell that details were dropped.
+const TruncatedPayloadMarker = "(payload truncated to fit size budget)"
+
+// EncodeErrorLimited is like EncodeError but keeps the encoded
+// representation under the given byte budget, e.g. to respect a gRPC
+// message size limit. When the full encoding exceeds maxBytes, the
+// least important parts are dropped first:
+//
+// 1. the safe detail strings other than flattened stack traces, and
+//    the structured payloads, at every level;
+// 2. the remaining safe details (the stack traces);
+// 3. the wrapper structure altogether: the error is re-encoded as a
+//    single leaf carrying only the top-level message, which is itself
+//    truncated as a last resort.
+//
+// Whenever anything was dropped, the marker TruncatedPayloadMarker is
+// recorded in the outermost level's safe details. Decoding a truncated
+// payload yields a usable error whose top-level message is intact
+// (except in the last-resort case above).
+//
+// A maxBytes of zero or less applies no limit.
+func EncodeErrorLimited(ctx context.Context, err error, maxBytes int) EncodedError {
+	enc := EncodeError(ctx, err)
+	if maxBytes <= 0 || enc.Size() <= maxBytes {
+		return enc
+	}
+
+	// Pass 1: drop the non-stack safe details and the structured
+	// payloads.
+	truncateDetails(&enc, true /* keepStacks */)
+	markTruncated(&enc)
+	if enc.Size() <= maxBytes {
+		return enc
+	}
+
+	// Pass 2: drop the stack traces too.
+	truncateDetails(&enc, false /* keepStacks */)
+	markTruncated(&enc)
+	if enc.Size() <= maxBytes {
+		return enc
+	}
+
+	// Pass 3: give up on the structure, keep only the message.
+	msg := err.Error()
+	mark := GetTypeMark(err)
+	mkLeaf := func(msg string) EncodedError {
+		return EncodedError{
+			Error: &errorspb.EncodedError_Leaf{
+				Leaf: &errorspb.EncodedErrorLeaf{
+					Message: msg,
+					Details: errorspb.EncodedErrorDetails{
+						OriginalTypeName: mark.FamilyName,
+						ErrorTypeMark:    mark,
+						ReportablePayload: []string{
+							TruncatedPayloadMarker,
+							wireVersionPrefix + strconv.Itoa(EncodedErrorWireVersion),
+						},
+					},
+				},
+			},
+		}
+	}
+	enc = mkLeaf(msg)
+	for enc.Size() > maxBytes && len(msg) > 0 {
+		half := len(msg) / 2
+		// Don't cut in the middle of a multi-byte rune.
+		for half > 0 && !utf8.RuneStart(msg[half]) {
+			half--
+		}
+		msg = msg[:half]
+		enc = mkLeaf(msg)
+	}
+	return enc
+}
+
+// truncateDetails removes the dispensable parts of the encoded
+// details at every level of enc: the structured payload always, and
+// the safe detail strings - all of them, or only those that are not
+// flattened stack traces when keepStacks is set.
+func truncateDetails(enc *EncodedError, keepStacks bool) {
+	var details *errorspb.EncodedErrorDetails
+	if w := enc.GetWrapper(); w != nil {
+		details = &w.Details
+		truncateDetails(&w.Cause, keepStacks)
+	} else if l := enc.GetLeaf(); l != nil {
+		details = &l.Details
+	} else {
+		return
+	}
+	details.FullDetails = nil
+	var kept []string
+	for _, d := range details.ReportablePayload {
+		if d == TruncatedPayloadMarker ||
+			strings.HasPrefix(d, wireVersionPrefix) ||
+			(keepStacks && looksLikeStackDetail(d)) {
+			kept = append(kept, d)
+		}
+	}
+	details.ReportablePayload = kept
+}
+
+// looksLikeStackDetail recognizes the flattened stack trace payloads
+// produced by the withstack package (which prefixes them with the Go
+// version that printed them, or a PC-blob marker), including those
+// explicitly retained across a masking boundary.
+func looksLikeStackDetail(d string) bool {
+	return strings.HasPrefix(d, StackDetailGoVersionPrefix) ||
+		strings.HasPrefix(d, StackDetailPCBlobPrefix) ||
+		strings.HasPrefix(d, StackDetailRetainedPrefix)
+}
+
+// markTruncated records TruncatedPayloadMarker in the outermost
+// level's safe details, if not already present.
+func markTruncated(enc *EncodedError) {
+	var details *errorspb.EncodedErrorDetails
+	if w := enc.GetWrapper(); w != nil {
+		details = &w.Details
+	} else if l := enc.GetLeaf(); l != nil {
+		details = &l.Details
+	} else {
+		return
+	}
+	for _, d := range details.ReportablePayload {
+		if d == TruncatedPayloadMarker {
+			return
+		}
+	}
+	details.ReportablePayload = append(details.ReportablePayload, TruncatedPayloadMarker)
+}
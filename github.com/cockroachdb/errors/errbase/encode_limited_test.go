@@ -0,0 +1,91 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package errbase_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/cockroachdb/errors/barriers"
+	"github.com/cockroachdb/errors/errbase"
+	"github.com/cockroachdb/errors/testutils"
+	"github.com/cockroachdb/errors/withstack"
+)
+
+// TestEncodeErrorLimitedRemainsDecodable checks that a payload
+// reduced by EncodeErrorLimited still decodes to a usable error, in
+// particular for barriers whose masked payload may have been dropped
+// by the truncation passes.
+func TestEncodeErrorLimitedRemainsDecodable(t *testing.T) {
+	tt := testutils.T{T: t}
+	ctx := context.Background()
+
+	origErr := barriers.Handled(withstack.WithStack(errors.New("hello")))
+	full := errbase.EncodeError(ctx, origErr)
+
+	// A first-pass reduction (payloads dropped, stacks kept)
+	// preserves the top-level message.
+	enc := errbase.EncodeErrorLimited(ctx, origErr, full.Size()-1)
+	dec := errbase.DecodeError(ctx, enc)
+	tt.Assert(dec != nil)
+	tt.CheckEqual(dec.Error(), origErr.Error())
+
+	// Harsher limits eventually reach the last-resort pass and may
+	// shorten the message, but decoding must still never fail.
+	for _, limit := range []int{full.Size() / 2, 100, 40, 10} {
+		enc := errbase.EncodeErrorLimited(ctx, origErr, limit)
+		dec := errbase.DecodeError(ctx, enc)
+		tt.Assert(dec != nil)
+	}
+}
+
+// TestEncodeErrorLimitedMarksTruncation checks that reduced payloads
+// carry the truncation marker in their outermost safe details.
+func TestEncodeErrorLimitedMarksTruncation(t *testing.T) {
+	tt := testutils.T{T: t}
+	ctx := context.Background()
+
+	origErr := barriers.Handled(withstack.WithStack(errors.New("hello")))
+	full := errbase.EncodeError(ctx, origErr)
+
+	enc := errbase.EncodeErrorLimited(ctx, origErr, full.Size()-1)
+	leaf := enc.GetLeaf()
+	tt.Assert(leaf != nil)
+	found := false
+	for _, d := range leaf.Details.ReportablePayload {
+		if d == errbase.TruncatedPayloadMarker {
+			found = true
+		}
+	}
+	tt.Check(found)
+}
+
+// TestEncodeErrorLimitedKeepsUTF8Valid checks that the last-resort
+// message halving does not cut a multi-byte rune in half.
+func TestEncodeErrorLimitedKeepsUTF8Valid(t *testing.T) {
+	tt := testutils.T{T: t}
+	ctx := context.Background()
+
+	origErr := errors.New(strings.Repeat("héllo wörld ", 20))
+	for limit := 200; limit > 40; limit -= 7 {
+		enc := errbase.EncodeErrorLimited(ctx, origErr, limit)
+		dec := errbase.DecodeError(ctx, enc)
+		tt.Assert(dec != nil)
+		tt.Check(utf8.ValidString(dec.Error()))
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package errbase
+
+// GetMessageChain returns the message contribution of each layer in
+// err's chain of causes as discrete items, from outermost to
+// innermost: for each wrapper, the prefix it added to its cause's
+// message (computed the same way as for network encoding, see
+// extractPrefix); for the final cause, its own message.
+//
+// Wrappers that add no message of their own (e.g. a bare WithStack)
+// contribute no item. For a chain built as
+//
+//	errors.Wrap(errors.Wrap(errors.New("connection refused"), "doing Y"), "doing X")
+//
+// the result is ["doing X", "doing Y", "connection refused"]: the
+// wrap-annotation breadcrumb as a list rather than one concatenated
+// string.
+func GetMessageChain(err error) []string {
+	var msgs []string
+	for ; err != nil; err = UnwrapOnce(err) {
+		if cause := UnwrapOnce(err); cause != nil {
+			if prefix := extractPrefix(err, cause); prefix != "" {
+				msgs = append(msgs, prefix)
+			}
+			continue
+		}
+		msgs = append(msgs, err.Error())
+	}
+	return msgs
+}
@@ -0,0 +1,77 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package errbase_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/errors/errbase"
+	"github.com/cockroachdb/errors/testutils"
+	"github.com/gogo/protobuf/proto"
+)
+
+// quotaExceeded is a domain error type with structured fields that
+// must survive an encode/decode cycle intact.
+type quotaExceeded struct {
+	Limit, Used int64
+}
+
+func (e *quotaExceeded) Error() string {
+	return fmt.Sprintf("quota exceeded: used %d of %d", e.Used, e.Limit)
+}
+
+func init() {
+	errbase.RegisterLeafEncoder(
+		errbase.GetTypeKey((*quotaExceeded)(nil)),
+		func(_ context.Context, err error) (msg string, safeDetails []string, payload proto.Message) {
+			e := err.(*quotaExceeded)
+			return "", []string{
+				fmt.Sprintf("limit=%d", e.Limit),
+				fmt.Sprintf("used=%d", e.Used),
+			}, nil
+		})
+	errbase.RegisterLeafDecoder(
+		errbase.GetTypeKey((*quotaExceeded)(nil)),
+		func(_ context.Context, _ string, safeDetails []string, _ proto.Message) error {
+			e := &quotaExceeded{}
+			for _, d := range safeDetails {
+				if _, err := fmt.Sscanf(d, "limit=%d", &e.Limit); err == nil {
+					continue
+				}
+				_, _ = fmt.Sscanf(d, "used=%d", &e.Used)
+			}
+			return e
+		})
+}
+
+// TestRegisteredLeafRoundTrip checks that a leaf error type with a
+// registered encoder/decoder pair round-trips through the network
+// with its structured payload reconstructed, instead of being
+// flattened to an opaque message string.
+func TestRegisteredLeafRoundTrip(t *testing.T) {
+	tt := testutils.T{T: t}
+	ctx := context.Background()
+
+	origErr := &quotaExceeded{Limit: 100, Used: 342}
+	dec := errbase.DecodeError(ctx, errbase.EncodeError(ctx, origErr))
+
+	decErr, ok := dec.(*quotaExceeded)
+	tt.Assert(ok)
+	tt.CheckEqual(decErr.Limit, origErr.Limit)
+	tt.CheckEqual(decErr.Used, origErr.Used)
+	tt.CheckEqual(dec.Error(), origErr.Error())
+}
@@ -0,0 +1,38 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package errbase
+
+// The constants below identify the flattened stack trace payloads
+// carried in safe detail strings. The payloads are produced and
+// consumed by package withstack; the markers are defined here, in the
+// package both sides already import, so that errbase (which withstack
+// depends on, and thus cannot import it) can recognize the payloads
+// without duplicating the literals.
+
+// StackDetailGoVersionPrefix heads a printed stack trace payload,
+// recording the Go runtime version that printed it.
+const StackDetailGoVersionPrefix = "go-version: "
+
+// StackDetailPCBlobPrefix heads a payload carrying base64-encoded raw
+// program counters instead of a printed stack trace.
+const StackDetailPCBlobPrefix = "pcblob:"
+
+// StackDetailRetainedPrefix heads a stack trace payload that an error
+// type other than the capturing one deliberately carries in its own
+// safe details, so that the stack remains reportable across a masking
+// boundary (see barriers.HandledWithSafeDetails). Stack payloads
+// without this marker are only recognized on the error types that
+// produced them.
+const StackDetailRetainedPrefix = "retained-stack: "
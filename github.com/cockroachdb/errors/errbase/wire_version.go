@@ -0,0 +1,173 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package errbase
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/errors/errorspb"
+)
+
+// EncodedErrorWireVersion is the version of the encoded error wire
+// format produced by this build of the library.
+//
+// Version history:
+//
+// 1: initial format. Encodings produced before versioning was
+//    introduced carry no version stamp and are treated as version 1.
+// 2: the flattened stack trace payloads in the safe details carry a
+//    leading "go-version: " header line recording the Go runtime that
+//    printed them (see StackDetailGoVersionPrefix).
+const EncodedErrorWireVersion = 2
+
+// wireVersionPrefix heads the safe detail string that carries the
+// wire version stamp on the outermost level of an encoded error. The
+// stamp travels in the safe details so that the wire format itself
+// needs no new field and pre-versioning decoders simply ignore it.
+const wireVersionPrefix = "encoded-at-version: "
+
+// EncodeErrorAtVersion is like EncodeError but produces an encoding
+// suitable for a peer that only understands the given wire version:
+// the features introduced after that version are dropped, and the
+// version stamp is adjusted accordingly.
+func EncodeErrorAtVersion(ctx context.Context, err error, v int) EncodedError {
+	enc := encodeError(ctx, err)
+	if v <= 1 {
+		// Version 1 payloads carry neither stack headers nor a stamp.
+		stripStackHeaders(&enc)
+		return enc
+	}
+	stampWireVersion(&enc, v)
+	return enc
+}
+
+// stripStackHeaders removes the Go version header from the flattened
+// stack payloads at every level of enc, restoring the version 1
+// layout of those details.
+func stripStackHeaders(enc *EncodedError) {
+	if w := enc.GetWrapper(); w != nil {
+		stripStackHeaders(&w.Cause)
+	}
+	details := outermostDetails(enc)
+	if details == nil {
+		return
+	}
+	for i, d := range details.ReportablePayload {
+		if strings.HasPrefix(d, StackDetailGoVersionPrefix) {
+			if idx := strings.IndexByte(d, '\n'); idx >= 0 {
+				details.ReportablePayload[i] = d[idx+1:]
+			} else {
+				// Header-only payload: no stack entries to keep.
+				details.ReportablePayload[i] = ""
+			}
+		}
+	}
+}
+
+// decodeWireVersionNotice checks the wire version stamped on the
+// encoded error against what this build understands and, on a
+// mismatch, wraps the decoded error with a hint noting that some
+// details may have been dropped. This is a no-op for encodings at a
+// known version, including unstamped (pre-versioning) ones.
+func decodeWireVersionNotice(enc EncodedError, decoded error) error {
+	if v := encodedWireVersion(enc); v > EncodedErrorWireVersion {
+		return &withVersionNotice{cause: decoded, version: v}
+	}
+	return decoded
+}
+
+// encodedWireVersion retrieves the wire version stamped on the
+// outermost level of the encoded error, defaulting to 1 for payloads
+// produced before versioning was introduced.
+func encodedWireVersion(enc EncodedError) int {
+	details := outermostDetails(&enc)
+	if details == nil {
+		return EncodedErrorWireVersion
+	}
+	for _, d := range details.ReportablePayload {
+		if strings.HasPrefix(d, wireVersionPrefix) {
+			if v, err := strconv.Atoi(strings.TrimSpace(d[len(wireVersionPrefix):])); err == nil {
+				return v
+			}
+		}
+	}
+	return 1
+}
+
+// stampWireVersion records the given wire version in the outermost
+// level's safe details, replacing any previous stamp.
+func stampWireVersion(enc *EncodedError, v int) {
+	details := outermostDetails(enc)
+	if details == nil {
+		return
+	}
+	var kept []string
+	for _, d := range details.ReportablePayload {
+		if !strings.HasPrefix(d, wireVersionPrefix) {
+			kept = append(kept, d)
+		}
+	}
+	details.ReportablePayload = append(kept, wireVersionPrefix+strconv.Itoa(v))
+}
+
+// outermostDetails accesses the details of the outermost level of the
+// encoded error, whether it is a wrapper or a leaf.
+func outermostDetails(enc *EncodedError) *errorspb.EncodedErrorDetails {
+	if w := enc.GetWrapper(); w != nil {
+		return &w.Details
+	}
+	if l := enc.GetLeaf(); l != nil {
+		return &l.Details
+	}
+	return nil
+}
+
+// withVersionNotice decorates an error decoded from a payload
+// encoded at a newer wire version than this build understands.
+type withVersionNotice struct {
+	cause   error
+	version int
+}
+
+var _ error = (*withVersionNotice)(nil)
+var _ fmt.Formatter = (*withVersionNotice)(nil)
+var _ Formatter = (*withVersionNotice)(nil)
+
+func (w *withVersionNotice) Error() string { return w.cause.Error() }
+func (w *withVersionNotice) Cause() error  { return w.cause }
+func (w *withVersionNotice) Unwrap() error { return w.cause }
+
+// ErrorHint implements hintdetail.ErrorHinter, without importing that
+// package (it imports this one); the notice thus surfaces through
+// GetAllHints() / FlattenHints().
+func (w *withVersionNotice) ErrorHint() string {
+	return fmt.Sprintf(
+		"error was encoded at wire version %d, but this build only understands up to version %d; "+
+			"some details may have been dropped during decoding",
+		w.version, EncodedErrorWireVersion)
+}
+
+func (w *withVersionNotice) Format(s fmt.State, verb rune) { FormatError(w, s, verb) }
+
+func (w *withVersionNotice) FormatError(p Printer) error {
+	if p.Detail() {
+		p.Printf("decoded from wire version %d (max understood: %d)",
+			w.version, EncodedErrorWireVersion)
+	}
+	return w.cause
+}
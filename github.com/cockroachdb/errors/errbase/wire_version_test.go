@@ -0,0 +1,125 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package errbase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/errors/testutils"
+)
+
+// TestWireVersionRoundTrip checks that EncodeError stamps the current
+// wire version on the outermost level only, and that decoding an
+// encoding at the current version attaches no notice.
+func TestWireVersionRoundTrip(t *testing.T) {
+	tt := testutils.T{T: t}
+	ctx := context.Background()
+
+	origErr := errors.New("world")
+	enc := EncodeError(ctx, &testWrapper{cause: origErr, msg: "hello"})
+	tt.CheckEqual(encodedWireVersion(enc), EncodedErrorWireVersion)
+
+	// The stamp only lives on the outermost level.
+	w := enc.GetWrapper()
+	tt.Assert(w != nil)
+	tt.CheckEqual(encodedWireVersion(w.Cause), 1)
+
+	dec := DecodeError(ctx, enc)
+	tt.CheckEqual(dec.Error(), "hello: world")
+	if _, ok := dec.(*withVersionNotice); ok {
+		t.Error("unexpected version notice on same-version decode")
+	}
+}
+
+// TestWireVersionMismatchHint checks that decoding an encoding
+// stamped with a newer version than this build understands yields a
+// usable error annotated with a hint about the mismatch.
+func TestWireVersionMismatchHint(t *testing.T) {
+	tt := testutils.T{T: t}
+	ctx := context.Background()
+
+	enc := EncodeError(ctx, errors.New("hello"))
+	stampWireVersion(&enc, EncodedErrorWireVersion+1)
+
+	dec := DecodeError(ctx, enc)
+	// The message is preserved regardless.
+	tt.CheckEqual(dec.Error(), "hello")
+
+	n, ok := dec.(*withVersionNotice)
+	tt.Assert(ok)
+	tt.Check(strings.Contains(n.ErrorHint(), "wire version"))
+}
+
+// testWrapper is a minimal wrapper type used to exercise the
+// multi-level encoding.
+type testWrapper struct {
+	cause error
+	msg   string
+}
+
+func (w *testWrapper) Error() string { return w.msg + ": " + w.cause.Error() }
+func (w *testWrapper) Unwrap() error { return w.cause }
+
+// stackDetailLeaf mimics an error whose safe details carry a
+// flattened stack payload, like withstack's withStack does. We cannot
+// use withstack directly here: it imports this package.
+type stackDetailLeaf struct{}
+
+func (stackDetailLeaf) Error() string { return "boom" }
+func (stackDetailLeaf) SafeDetails() []string {
+	return []string{StackDetailGoVersionPrefix + "go1.21\nfoo.com/pkg.Fn\n\t/src/foo/file.go:42"}
+}
+
+// TestEncodeErrorAtVersionDowngrades checks that encoding at version
+// 1 removes the stack payload headers and emits no version stamp,
+// reproducing the pre-versioning layout.
+func TestEncodeErrorAtVersionDowngrades(t *testing.T) {
+	tt := testutils.T{T: t}
+	ctx := context.Background()
+
+	enc := EncodeErrorAtVersion(ctx, stackDetailLeaf{}, 1)
+	leaf := enc.GetLeaf()
+	tt.Assert(leaf != nil)
+	for _, d := range leaf.Details.ReportablePayload {
+		tt.Check(!strings.HasPrefix(d, wireVersionPrefix))
+		tt.Check(!strings.HasPrefix(d, StackDetailGoVersionPrefix))
+	}
+	tt.CheckEqual(encodedWireVersion(enc), 1)
+
+	// Encoding at the current version keeps both.
+	enc = EncodeErrorAtVersion(ctx, stackDetailLeaf{}, EncodedErrorWireVersion)
+	tt.CheckEqual(encodedWireVersion(enc), EncodedErrorWireVersion)
+	leaf = enc.GetLeaf()
+	tt.Assert(leaf != nil)
+	tt.Assert(len(leaf.Details.ReportablePayload) > 0)
+	tt.Check(strings.HasPrefix(leaf.Details.ReportablePayload[0], StackDetailGoVersionPrefix))
+}
+
+// TestEncodeErrorLimitedKeepsWireVersion checks that the truncation
+// passes preserve the version stamp.
+func TestEncodeErrorLimitedKeepsWireVersion(t *testing.T) {
+	tt := testutils.T{T: t}
+	ctx := context.Background()
+
+	origErr := stackDetailLeaf{}
+	full := EncodeError(ctx, origErr)
+	for _, limit := range []int{full.Size() - 1, 40, 10} {
+		enc := EncodeErrorLimited(ctx, origErr, limit)
+		tt.CheckEqual(encodedWireVersion(enc), EncodedErrorWireVersion)
+	}
+}
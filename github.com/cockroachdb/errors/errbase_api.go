@@ -32,6 +32,11 @@ type EncodedError = errbase.EncodedError
 // EncodeError forwards a definition.
 func EncodeError(ctx context.Context, err error) EncodedError { return errbase.EncodeError(ctx, err) }
 
+// EncodeErrorAtVersion forwards a definition.
+func EncodeErrorAtVersion(ctx context.Context, err error, v int) EncodedError {
+	return errbase.EncodeErrorAtVersion(ctx, err, v)
+}
+
 // DecodeError forwards a definition.
 func DecodeError(ctx context.Context, enc EncodedError) error { return errbase.DecodeError(ctx, enc) }
 
@@ -19,6 +19,7 @@ import (
 	"fmt"
 
 	"github.com/cockroachdb/errors/errbase"
+	"github.com/cockroachdb/errors/withstack"
 	"github.com/gogo/protobuf/proto"
 )
 
@@ -46,6 +47,32 @@ func WithMessagef(err error, format string, args ...interface{}) error {
 	}
 }
 
+// WithMessageAndStack annotates err with a new message and ensures a
+// stack trace is present: if no level of err's chain already carries
+// one, a stack is captured at the call site. The detection reuses the
+// logic of withstack.GetReportableStackTrace (see withstack.HasStack),
+// so a trace attached by any supported means - this library,
+// github.com/pkg/errors, or a decoded network payload - prevents a
+// second capture.
+// If err is nil, WithMessageAndStack returns nil.
+func WithMessageAndStack(err error, message string) error {
+	return WithMessageAndStackDepth(1, err, message)
+}
+
+// WithMessageAndStackDepth is like WithMessageAndStack() except the
+// depth at which the stack (if any) is captured is configurable.
+// See the doc of `WithMessageAndStack()` for more details.
+func WithMessageAndStackDepth(depth int, err error, message string) error {
+	if err == nil {
+		return nil
+	}
+	res := error(&withMessage{cause: err, msg: message})
+	if !withstack.HasStack(err) {
+		res = withstack.WithStackDepth(res, depth+1)
+	}
+	return res
+}
+
 type withMessage struct {
 	cause error
 	msg   string
@@ -0,0 +1,57 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package errutil_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cockroachdb/errors/errbase"
+	"github.com/cockroachdb/errors/errutil"
+	"github.com/cockroachdb/errors/testutils"
+	"github.com/cockroachdb/errors/withstack"
+)
+
+// countStacks reports how many levels of the error's chain carry
+// their own stack trace annotation.
+func countStacks(err error) int {
+	n := 0
+	for c := err; c != nil; c = errbase.UnwrapOnce(c) {
+		if withstack.GetReportableStackTrace(c) != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// TestWithMessageAndStack checks that the wrapper captures a stack
+// only when the cause does not already carry one: annotating an
+// already-traced error must not add a second stack.
+func TestWithMessageAndStack(t *testing.T) {
+	tt := testutils.T{T: t}
+
+	// A stackless cause gets a stack captured at the call site.
+	err := errutil.WithMessageAndStack(errors.New("hello"), "woo")
+	tt.CheckEqual(err.Error(), "woo: hello")
+	tt.CheckEqual(countStacks(err), 1)
+
+	// A cause that already has a stack, at any depth, prevents a
+	// second capture.
+	err = errutil.WithMessageAndStack(withstack.WithStack(errors.New("hello")), "woo")
+	tt.CheckEqual(err.Error(), "woo: hello")
+	tt.CheckEqual(countStacks(err), 1)
+
+	tt.Check(errutil.WithMessageAndStack(nil, "woo") == nil)
+}
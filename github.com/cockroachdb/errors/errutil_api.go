@@ -72,6 +72,11 @@ func WithMessagef(err error, format string, args ...interface{}) error {
 	return errutil.WithMessagef(err, format, args...)
 }
 
+// WithMessageAndStack forwards a definition.
+func WithMessageAndStack(err error, msg string) error {
+	return errutil.WithMessageAndStackDepth(1, err, msg)
+}
+
 // Wrap forwards a definition.
 func Wrap(err error, msg string) error { return errutil.WrapWithDepth(1, err, msg) }
 
@@ -39,6 +39,9 @@ func WithHint(err error, msg string) error {
 }
 
 // WithHintf is a helper that formats the hint.
+// See the documentation of WithHint() for details.
+// The fully formatted strings are reported by GetAllHints() /
+// FlattenHints() in attachment order (outermost hint last).
 func WithHintf(err error, format string, args ...interface{}) error {
 	if err == nil {
 		return nil
@@ -48,7 +51,10 @@ func WithHintf(err error, format string, args ...interface{}) error {
 }
 
 // GetAllHints retrieves the hints from the error using in post-order
-// traversal. The hints are de-duplicated. Assertion failures, issue
+// traversal. The hints are de-duplicated: byte-identical hint strings
+// attached at multiple levels appear only once, at their first-seen
+// (innermost) position; hints that differ in any way, including
+// whitespace, are preserved separately. Assertion failures, issue
 // links and unimplemented errors are detected and receive standard
 // hints.
 func GetAllHints(err error) []string {
@@ -114,6 +120,9 @@ func WithDetail(err error, msg string) error {
 }
 
 // WithDetailf is a helper that formats the detail string.
+// See the documentation of WithDetail() for details.
+// The fully formatted strings are reported by GetAllDetails() /
+// FlattenDetails() in attachment order (outermost detail last).
 func WithDetailf(err error, format string, args ...interface{}) error {
 	if err == nil {
 		return nil
@@ -0,0 +1,58 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package hintdetail_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cockroachdb/errors/hintdetail"
+	"github.com/cockroachdb/errors/testutils"
+)
+
+// TestGetAllHintsOrder checks that hints attached at multiple wrap
+// levels all accumulate and are reported in attachment order, the
+// outermost hint last.
+func TestGetAllHintsOrder(t *testing.T) {
+	tt := testutils.T{T: t}
+
+	err := errors.New("hello")
+	err = hintdetail.WithHint(err, "inner hint")
+	err = hintdetail.WithHintf(err, "middle hint %d", 2)
+	err = hintdetail.WithHint(err, "outer hint")
+
+	tt.CheckDeepEqual(hintdetail.GetAllHints(err),
+		[]string{"inner hint", "middle hint 2", "outer hint"})
+}
+
+// TestGetAllHintsDedup checks the de-duplication semantics: only
+// byte-identical hints are collapsed, at their first-seen (innermost)
+// position, while hints that differ only in whitespace are preserved
+// separately.
+func TestGetAllHintsDedup(t *testing.T) {
+	tt := testutils.T{T: t}
+
+	err := errors.New("hello")
+	err = hintdetail.WithHint(err, "try again")
+	err = hintdetail.WithHint(err, "other hint")
+	// Byte-identical repeat: collapsed into the first occurrence.
+	err = hintdetail.WithHint(err, "try again")
+	// Near-duplicates differing only in whitespace: kept as-is.
+	err = hintdetail.WithHint(err, "try again ")
+	err = hintdetail.WithHint(err, "try  again")
+
+	tt.CheckDeepEqual(hintdetail.GetAllHints(err),
+		[]string{"try again", "other hint", "try again ", "try  again"})
+}
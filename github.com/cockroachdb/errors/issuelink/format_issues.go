@@ -0,0 +1,49 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package issuelink
+
+import "bytes"
+
+// FormatWithIssues renders the error's message followed by a
+// "Referenced issues:" section listing the URL and detail of every
+// linked issue found in the chain of causes, one per line, from
+// outermost to innermost annotation. When the error carries no issue
+// link, the result is just the message.
+//
+// This is meant for user-facing output where the issue references
+// should stand out instead of being buried in the `%+v` verbose
+// format.
+func FormatWithIssues(err error) string {
+	if err == nil {
+		return ""
+	}
+	issues := GetAllIssueLinks(err)
+	if len(issues) == 0 {
+		return err.Error()
+	}
+	var b bytes.Buffer
+	b.WriteString(err.Error())
+	b.WriteString("\nReferenced issues:")
+	for _, issue := range issues {
+		b.WriteString("\n  ")
+		b.WriteString(issue.IssueURL)
+		if issue.Detail != "" {
+			b.WriteString(" (")
+			b.WriteString(issue.Detail)
+			b.WriteString(")")
+		}
+	}
+	return b.String()
+}
@@ -35,6 +35,9 @@ func UnimplementedErrorf(issueLink IssueLink, format string, args ...interface{}
 // GetAllIssueLinks forwards a definition.
 func GetAllIssueLinks(err error) (issues []IssueLink) { return issuelink.GetAllIssueLinks(err) }
 
+// FormatWithIssues forwards a definition.
+func FormatWithIssues(err error) string { return issuelink.FormatWithIssues(err) }
+
 // HasIssueLink forwards a definition.
 func HasIssueLink(err error) bool { return issuelink.HasIssueLink(err) }
 
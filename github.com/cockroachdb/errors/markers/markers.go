@@ -204,7 +204,12 @@ func safeGetErrMsg(err error) (result string) {
 }
 
 // Mark creates an explicit mark for the given error, using
-// the same mark as some reference error.
+// the same mark as some reference error: afterwards,
+// `Is(result, reference)` returns true while the result's message and
+// chain of causes are unchanged. The mark is carried by the encoded
+// representation (it is derived from the reference's message and type
+// keys), so the equivalence also holds after a round-trip through
+// EncodeError()/DecodeError().
 //
 // Note: if any of the error types has been migrated from a previous
 // package location or a different type, ensure that
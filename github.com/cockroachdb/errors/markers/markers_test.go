@@ -0,0 +1,44 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package markers_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cockroachdb/errors/errbase"
+	"github.com/cockroachdb/errors/markers"
+	"github.com/cockroachdb/errors/testutils"
+)
+
+// TestMarkSurvivesEncodeDecode checks that the identity borrowed via
+// Mark() keeps matching with Is() after the error has traveled
+// through the network.
+func TestMarkSurvivesEncodeDecode(t *testing.T) {
+	tt := testutils.T{T: t}
+	ctx := context.Background()
+
+	refErr := errors.New("reference")
+	err := markers.Mark(errors.New("hello"), refErr)
+	tt.Assert(markers.Is(err, refErr))
+
+	dec := errbase.DecodeError(ctx, errbase.EncodeError(ctx, err))
+	tt.Check(markers.Is(dec, refErr))
+
+	// The mark is specific: the decoded error does not match an
+	// unrelated reference.
+	tt.Check(!markers.Is(dec, errors.New("other")))
+}
@@ -0,0 +1,70 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package report
+
+import (
+	"sync"
+
+	"github.com/cockroachdb/errors/errbase"
+)
+
+// GetRootTypeKey returns the errbase type key of the innermost error
+// in err's chain of causes, as a string.
+//
+// This is useful as a grouping fingerprint component: two errors with
+// similar stack traces but different root causes (e.g. a *net.OpError
+// vs a validation error) will produce different keys.
+//
+// Note that barriers (errors.Handled) hide their masked cause from
+// Cause()/Unwrap(); the key returned for a barrier-protected error is
+// thus the barrier's own type key, consistent with the visibility
+// rules applied by errors.Is().
+func GetRootTypeKey(err error) string {
+	return string(errbase.GetTypeKey(errbase.UnwrapAll(err)))
+}
+
+// fingerprintMu protects fingerprintFn.
+var fingerprintMu sync.RWMutex
+
+// fingerprintFn, when set, computes a custom grouping fingerprint for
+// Sentry events. See SetFingerprintFn().
+var fingerprintFn func(err error) []string
+
+// SetFingerprintFn registers a function that computes the Sentry
+// grouping fingerprint for reported errors. When the function returns
+// a non-empty slice, BuildSentryReport() stores it in the event's
+// Fingerprint field, overriding Sentry's default stack-based
+// grouping; the stack traces are still attached to the event as
+// usual. When no function is registered, or when the function returns
+// an empty result for a given error, the event is left without an
+// explicit fingerprint and Sentry groups it by stack as before.
+//
+// A typical fingerprint combines stable properties of the error, for
+// example GetRootTypeKey() above and a telemetry key, so that the
+// same logical failure groups together regardless of the call site.
+//
+// This is safe to call concurrently with BuildSentryReport(), though
+// it is best called once during initialization.
+func SetFingerprintFn(fn func(err error) []string) {
+	fingerprintMu.Lock()
+	defer fingerprintMu.Unlock()
+	fingerprintFn = fn
+}
+
+func getFingerprintFn() func(err error) []string {
+	fingerprintMu.RLock()
+	defer fingerprintMu.RUnlock()
+	return fingerprintFn
+}
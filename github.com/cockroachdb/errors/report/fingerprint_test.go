@@ -0,0 +1,38 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package report
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/errors/errbase"
+	"github.com/cockroachdb/errors/testutils"
+)
+
+// TestGetRootTypeKeyMultiLayer checks that GetRootTypeKey reaches the
+// innermost leaf of a multi-layer chain and reports its type key, not
+// a wrapper's.
+func TestGetRootTypeKeyMultiLayer(t *testing.T) {
+	tt := testutils.T{T: t}
+
+	leaf := errors.New("hello")
+	err := fmt.Errorf("outer: %w", fmt.Errorf("middle: %w", fmt.Errorf("inner: %w", leaf)))
+
+	tt.CheckEqual(GetRootTypeKey(err), string(errbase.GetTypeKey(leaf)))
+	// Sanity check: a wrapper's own key differs from the leaf's.
+	tt.Check(GetRootTypeKey(err) != string(errbase.GetTypeKey(err)))
+}
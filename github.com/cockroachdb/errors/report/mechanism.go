@@ -0,0 +1,57 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package report
+
+import (
+	"reflect"
+	"runtime"
+
+	"github.com/cockroachdb/errors/assert"
+	"github.com/cockroachdb/errors/errbase"
+)
+
+// GetExceptionMechanism classifies how the given error was captured,
+// in the vocabulary used by Sentry's exception "mechanism" field.
+//
+// The classification inspects the chain of causes for markers:
+//
+//   - "assertion" when the chain carries an assertion failure
+//     (see assert.WithAssertionFailure);
+//   - "panic" when a layer is a runtime.Error, i.e. the value
+//     recovered from a run-time panic (nil dereference, slice
+//     overflow, ...);
+//   - "grpc" when a layer carries a gRPC status (detected by the
+//     presence of a GRPCStatus() method, so no dependency on a
+//     particular gRPC library is needed);
+//   - "generic" otherwise.
+//
+// This complements the stack trace with a capture-method
+// classification that a Sentry integration can attach to the event.
+func GetExceptionMechanism(err error) string {
+	if assert.HasAssertionFailure(err) {
+		return "assertion"
+	}
+	for c := err; c != nil; c = errbase.UnwrapOnce(c) {
+		if _, ok := c.(runtime.Error); ok {
+			return "panic"
+		}
+		if t := reflect.TypeOf(c); t != nil {
+			if _, ok := t.MethodByName("GRPCStatus"); ok {
+				return "grpc"
+			}
+		}
+	}
+	return "generic"
+}
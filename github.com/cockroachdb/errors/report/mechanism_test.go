@@ -0,0 +1,53 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package report
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/errors/testutils"
+)
+
+// myPanicker is an ordinary error type whose name merely contains
+// "panic".
+type myPanicker struct{}
+
+func (myPanicker) Error() string { return "not a real panic" }
+
+// TestGetExceptionMechanismPanic checks that the "panic"
+// classification keys off actual recovered run-time panics
+// (runtime.Error values), not off error type names.
+func TestGetExceptionMechanismPanic(t *testing.T) {
+	tt := testutils.T{T: t}
+
+	// Recover a genuine run-time panic.
+	var rtErr error
+	func() {
+		defer func() {
+			rtErr = recover().(error)
+		}()
+		var p *int
+		_ = *p //lint:ignore SA5011 deliberate nil dereference
+	}()
+	tt.CheckEqual(GetExceptionMechanism(rtErr), "panic")
+	// Including when it sits behind wrappers.
+	tt.CheckEqual(GetExceptionMechanism(fmt.Errorf("recovered: %w", rtErr)), "panic")
+
+	// A type that merely mentions "panic" in its name is not one.
+	tt.CheckEqual(GetExceptionMechanism(myPanicker{}), "generic")
+	tt.CheckEqual(GetExceptionMechanism(errors.New("panic elsewhere")), "generic")
+}
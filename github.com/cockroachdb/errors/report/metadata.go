@@ -0,0 +1,81 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package report
+
+import (
+	"runtime"
+	"runtime/debug"
+
+	"github.com/cockroachdb/errors/contexttags"
+	"github.com/cockroachdb/errors/withstack"
+)
+
+// AnnotatedStackTrace extends a reportable stack trace with free-form
+// key/value metadata about the capture. It is produced by
+// GetReportableStackTraceWithMetadata.
+type AnnotatedStackTrace struct {
+	*withstack.ReportableStackTrace
+
+	// StackMetadata carries string key/value pairs describing the
+	// capture: automatically-collected values (build identification,
+	// context tags attached to the error) merged with any
+	// caller-supplied entries.
+	StackMetadata map[string]string
+}
+
+// GetReportableStackTraceWithMetadata extracts the stack trace
+// embedded in the given error (like withstack.GetReportableStackTrace)
+// and decorates it with key/value metadata. This is the general escape
+// hatch for reporting pipelines that need to attach values not covered
+// by a dedicated feature (compare withstack.WithTraceID): the
+// caller-supplied extra map is carried through as-is.
+//
+// The metadata always includes:
+// - "go_version": the Go runtime that captured the stack;
+// - "build_id": the main module's path and version, when build
+//   information is available;
+// - one entry per context tag attached to the error via
+//   contexttags.WithContextTags, outermost annotation winning on key
+//   collision.
+// Entries from extra override automatically-collected entries with
+// the same key.
+//
+// The result is nil when no stack trace is available.
+func GetReportableStackTraceWithMetadata(
+	err error, extra map[string]string,
+) *AnnotatedStackTrace {
+	st := withstack.GetReportableStackTrace(err)
+	if st == nil {
+		return nil
+	}
+	md := map[string]string{
+		"go_version": runtime.Version(),
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		md["build_id"] = bi.Main.Path + "@" + bi.Main.Version
+	}
+	// GetContextTags returns the outermost annotation first; apply the
+	// buffers innermost first so that outer tags override inner ones.
+	tagBuffers := contexttags.GetContextTags(err)
+	for i := len(tagBuffers) - 1; i >= 0; i-- {
+		for _, tag := range tagBuffers[i].Get() {
+			md[tag.Key()] = tag.ValueStr()
+		}
+	}
+	for k, v := range extra {
+		md[k] = v
+	}
+	return &AnnotatedStackTrace{ReportableStackTrace: st, StackMetadata: md}
+}
@@ -19,8 +19,10 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/cockroachdb/errors/contexttags"
 	"github.com/cockroachdb/errors/domains"
 	"github.com/cockroachdb/errors/errbase"
+	"github.com/cockroachdb/errors/safedetails"
 	"github.com/cockroachdb/errors/withstack"
 	"github.com/cockroachdb/sentry-go"
 )
@@ -76,7 +78,9 @@ import (
 //
 // (1)/(7): <filename>:<lineno> (<functionname>)
 // (3)/(8): <error type>: <first safe detail line, if any>
-// (4): not populated in this function, caller is to manage this
+// (4): populated from the context tags in the error chain, if any
+//      (outermost annotations take precedence, values are redacted);
+//      the caller can add more tags afterwards
 // (5): detailed structure of the entire error object, with references to "additional data"
 //      and additional "exception" objects
 // (9): generated from innermost stack trace
@@ -293,6 +297,31 @@ func BuildSentryReport(err error) (event *sentry.Event, extraDetails map[string]
 	event.Message = longMsgBuf.String()
 	event.Exception = exceptions
 
+	// If a custom grouping fingerprint function was registered, let it
+	// override Sentry's default stack-based grouping.
+	if fn := getFingerprintFn(); fn != nil {
+		if fp := fn(err); len(fp) > 0 {
+			event.Fingerprint = fp
+		}
+	}
+
+	// Promote the context tags attached to the error chain (via
+	// contexttags.WithContextTags) into the event's tags.
+	//
+	// Precedence on key collision: annotations closer to the outside
+	// of the error chain override those closer to the leaf. The values
+	// are passed through the safe-details redaction, so they cannot
+	// leak PII.
+	//
+	// GetContextTags returns the outermost annotation first; apply the
+	// buffers innermost first so that outer tags overwrite inner ones.
+	tagBuffers := contexttags.GetContextTags(err)
+	for i := len(tagBuffers) - 1; i >= 0; i-- {
+		for _, tag := range tagBuffers[i].Get() {
+			event.Tags[tag.Key()] = safedetails.Redact(tag.Value())
+		}
+	}
+
 	// If there is no exception payload, synthetize one.
 	if len(event.Exception) == 0 {
 		// We know we don't have a stack trace to extract line/function
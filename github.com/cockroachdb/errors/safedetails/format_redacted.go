@@ -0,0 +1,91 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package safedetails
+
+import (
+	"strings"
+
+	"github.com/cockroachdb/errors/errbase"
+	"github.com/cockroachdb/errors/withstack"
+)
+
+// RedactedMarker substitutes every unsafe message component in the
+// output of FormatRedacted. The guillemets make it distinguishable
+// from a literal "<redacted>" occurring in the original data.
+const RedactedMarker = "‹redacted›"
+
+// FormatRedacted renders the error with every potentially-PII-laden
+// component replaced by RedactedMarker, suitable for audit logs that
+// may be archived long-term.
+//
+// The output mirrors the shape of err.Error(): one component per
+// message-bearing level of the chain, outermost first, joined with
+// ": ". A level's own message is shown only if the level implements
+// SafeMessager (e.g. errors wrapped with Safe()); every other
+// message is replaced by the marker. The safe detail strings attached
+// at each level - which are PII-free by construction, e.g. the format
+// string and Safe() arguments recorded by WithSafeDetails() - are
+// appended after the component they belong to, except for the
+// flattened stack trace payloads which are omitted.
+//
+// The output is deterministic: it depends only on the error chain,
+// not on the environment.
+func FormatRedacted(err error) string {
+	if err == nil {
+		return ""
+	}
+	var parts []string
+	for e := err; e != nil; e = errbase.UnwrapOnce(e) {
+		var part strings.Builder
+		if msgBearing(e) {
+			if sm, ok := e.(SafeMessager); ok {
+				part.WriteString(sm.SafeMessage())
+			} else {
+				part.WriteString(RedactedMarker)
+			}
+		}
+		if sd, ok := e.(errbase.SafeDetailer); ok {
+			for _, d := range sd.SafeDetails() {
+				if r, ok := withstack.RetainedStackDetail(d); ok {
+					d = r
+				}
+				if withstack.IsStackDetail(d) {
+					continue
+				}
+				if _, ok := withstack.DecodePCBlob(d); ok {
+					continue
+				}
+				if part.Len() > 0 {
+					part.WriteString(" ")
+				}
+				part.WriteString("(")
+				part.WriteString(strings.TrimSpace(d))
+				part.WriteString(")")
+			}
+		}
+		if part.Len() > 0 {
+			parts = append(parts, part.String())
+		}
+	}
+	return strings.Join(parts, ": ")
+}
+
+// msgBearing reports whether the given level of the chain contributes
+// a message component of its own, i.e. is a leaf or a wrapper that
+// does more than pass its cause's message through.
+func msgBearing(err error) bool {
+	c := errbase.UnwrapOnce(err)
+	return c == nil || err.Error() != c.Error()
+}
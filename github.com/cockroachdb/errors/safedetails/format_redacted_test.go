@@ -0,0 +1,57 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package safedetails_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/errors/safedetails"
+	"github.com/cockroachdb/errors/testutils"
+)
+
+// TestFormatRedacted checks the redacted rendering of a chain mixing
+// safe and unsafe components across several wrap levels: unsafe
+// message text never leaks, while Safe() arguments and format strings
+// remain visible.
+func TestFormatRedacted(t *testing.T) {
+	tt := testutils.T{T: t}
+
+	err := errors.New("user=bob is broken")
+	err = safedetails.WithSafeDetails(err, "read failed on %s",
+		safedetails.Safe("system.descriptor"))
+	err = fmt.Errorf("attempt for carl: %w", err)
+	err = safedetails.WithSafeDetails(err, "retry %d of %s",
+		safedetails.Safe(2), "carl's request")
+
+	out := safedetails.FormatRedacted(err)
+
+	// The unsafe leaf message and wrapper prefix are replaced by the
+	// marker; neither leaks, including the unsafe detail argument.
+	tt.Check(strings.Contains(out, safedetails.RedactedMarker))
+	tt.Check(!strings.Contains(out, "bob"))
+	tt.Check(!strings.Contains(out, "carl"))
+
+	// The safe components survive: format strings and Safe() args.
+	tt.Check(strings.Contains(out, "read failed on %s"))
+	tt.Check(strings.Contains(out, "system.descriptor"))
+	tt.Check(strings.Contains(out, "retry %d of %s"))
+
+	// The rendering is deterministic.
+	tt.CheckEqual(safedetails.FormatRedacted(err), out)
+	tt.CheckEqual(safedetails.FormatRedacted(nil), "")
+}
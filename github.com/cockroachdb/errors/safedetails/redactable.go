@@ -0,0 +1,63 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package safedetails
+
+import "fmt"
+
+// RedactableSprintf formats the arguments once and returns both the
+// full rendering, for local logs, and a redacted rendering, for
+// remote telemetry.
+//
+// In the redacted result, arguments wrapped with Safe() (or
+// implementing SafeMessager) appear as in the full result, and every
+// other argument is replaced by the "<redacted>" placeholder.
+//
+// The format string is interpreted by fmt in both outputs: verbs,
+// widths, and the usual %!-style error markers for mismatched verb
+// and argument counts behave identically in the two renderings.
+func RedactableSprintf(format string, args ...interface{}) (full string, redacted string) {
+	fullArgs := make([]interface{}, len(args))
+	redactedArgs := make([]interface{}, len(args))
+	for i, a := range args {
+		if s, ok := a.(safeType); ok {
+			// Unwrap the Safe() marker, so that the underlying value
+			// meets the format verb directly in both outputs.
+			fullArgs[i] = s.V
+			redactedArgs[i] = s.V
+			continue
+		}
+		fullArgs[i] = a
+		if _, ok := a.(SafeMessager); ok {
+			// Another self-redacting type: its rendering is safe as-is.
+			redactedArgs[i] = a
+		} else {
+			redactedArgs[i] = redactedPlaceholder{}
+		}
+	}
+	return fmt.Sprintf(format, fullArgs...), fmt.Sprintf(format, redactedArgs...)
+}
+
+// redactedPlaceholder substitutes a sensitive argument in the
+// redacted output of RedactableSprintf. It renders as "<redacted>"
+// under every format verb, so the redacted string keeps the shape of
+// the full one.
+type redactedPlaceholder struct{}
+
+var _ fmt.Formatter = redactedPlaceholder{}
+
+// Format implements the fmt.Formatter interface.
+func (redactedPlaceholder) Format(s fmt.State, _ rune) {
+	fmt.Fprint(s, "<redacted>")
+}
@@ -30,3 +30,11 @@ func Safe(v interface{}) SafeMessager { return safedetails.Safe(v) }
 // Redact returns a redacted version of the supplied item that is safe
 // to use in anonymized reporting.
 func Redact(r interface{}) string { return safedetails.Redact(r) }
+
+// FormatRedacted forwards a definition.
+func FormatRedacted(err error) string { return safedetails.FormatRedacted(err) }
+
+// RedactableSprintf forwards a definition.
+func RedactableSprintf(format string, args ...interface{}) (full string, redacted string) {
+	return safedetails.RedactableSprintf(format, args...)
+}
@@ -0,0 +1,56 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package secondary
+
+import "github.com/cockroachdb/errors/markers"
+
+// CombineN combines an arbitrary number of errors into one.
+//
+// Nil entries are ignored. If all entries are nil, the result is nil;
+// if exactly one entry is non-nil, that error is returned unwrapped.
+//
+// Otherwise, the first non-nil error becomes the primary error and
+// the remaining ones are attached in order as secondary errors, so
+// the result is flat rather than the lopsided tree produced by
+// repeated CombineErrors() calls. The result is additionally marked
+// with the identity of every combined error, so that `errors.Is`
+// matches the result against any of them, not just the primary -
+// including after an encode/decode cycle, since both the secondary
+// payloads and the marks travel across the network.
+func CombineN(errs ...error) error {
+	var nonNil []error
+	for _, e := range errs {
+		if e != nil {
+			nonNil = append(nonNil, e)
+		}
+	}
+	if len(nonNil) == 0 {
+		return nil
+	}
+	if len(nonNil) == 1 {
+		return nonNil[0]
+	}
+	res := nonNil[0]
+	for _, e := range nonNil[1:] {
+		res = WithSecondaryError(res, e)
+	}
+	// The secondary errors do not participate in cause analysis
+	// (see WithSecondaryError); restore `Is` visibility with a mark
+	// per combined error.
+	for _, e := range nonNil[1:] {
+		res = markers.Mark(res, e)
+	}
+	return res
+}
@@ -0,0 +1,42 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package secondary
+
+import "github.com/cockroachdb/errors/errbase"
+
+// GetSecondaryErrors retrieves the secondary errors attached at any
+// level of the error's chain of causes (see WithSecondaryError), in
+// attachment order: the secondary attached at the innermost level
+// comes first. The result is nil when there are none.
+//
+// When deep is set, the visit also recurses into each secondary
+// error's own chain, so secondaries-of-secondaries are included,
+// each immediately after the secondary that carries them.
+func GetSecondaryErrors(err error, deep bool) []error {
+	return collectSecondaries(err, nil /* res */, deep)
+}
+
+func collectSecondaries(err error, res []error, deep bool) []error {
+	if c := errbase.UnwrapOnce(err); c != nil {
+		res = collectSecondaries(c, res, deep)
+	}
+	if w, ok := err.(*withSecondaryError); ok {
+		res = append(res, w.secondaryError)
+		if deep {
+			res = collectSecondaries(w.secondaryError, res, deep)
+		}
+	}
+	return res
+}
@@ -16,9 +16,12 @@ package secondary
 
 // WithSecondaryError enhances the error given as first argument with
 // an annotation that carries the error given as second argument.  The
-// second error does not participate in cause analysis (Is, etc) and
-// is only revealed when printing out the error or collecting safe
-// (PII-free) details for reporting.
+// second error does not participate in this library's cause analysis
+// (Is, etc) and is only revealed when printing out the error or
+// collecting safe (PII-free) details for reporting. However the
+// result implements the Go 1.20 multi-cause interface (`Unwrap()
+// []error`), so the standard library's errors.Is / errors.As do
+// traverse into the secondary error on Go 1.20 and later.
 //
 // If additionalErr is nil, the first error is returned as-is.
 //
@@ -0,0 +1,87 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package secondary
+
+import (
+	"reflect"
+
+	"github.com/cockroachdb/errors/errbase"
+)
+
+// WalkDeep visits every error in err's tree of causes, from the
+// outermost to the innermost level, and calls fn on each. Unlike a
+// plain UnwrapOnce() loop, the visit also branches into secondary
+// error payloads (see WithSecondaryError) and into the children of
+// multi-cause errors exposing an `Unwrap() []error` method.
+//
+// The walk stops early, returning true, as soon as fn returns true;
+// otherwise every error is visited and the result is false.
+//
+// Errors already visited are not visited again, so a cycle in the
+// cause relationships does not loop forever.
+func WalkDeep(err error, fn func(err error) bool) bool {
+	visited := map[error]struct{}{}
+	return walkDeep(err, fn, visited)
+}
+
+// GetAllSafeDetailsDeep collects the safe details from the given
+// error and every error in its tree of causes, like
+// errbase.GetAllSafeDetails, but additionally visiting secondary
+// error payloads and the children of multi-cause errors (see
+// WalkDeep). Each entry records the originating error type, so a
+// telemetry blob can attribute every detail string. Errors that do
+// not implement SafeDetailer yield an entry with no detail strings.
+//
+// The details are collected from the outermost to the innermost
+// level of cause.
+func GetAllSafeDetailsDeep(err error) []errbase.SafeDetailPayload {
+	var details []errbase.SafeDetailPayload
+	WalkDeep(err, func(e error) bool {
+		details = append(details, errbase.GetSafeDetails(e))
+		return false
+	})
+	return details
+}
+
+func walkDeep(err error, fn func(err error) bool, visited map[error]struct{}) bool {
+	for err != nil {
+		// Only comparable errors can be tracked in the visited set;
+		// for the others we rely on the chain being finite.
+		if reflect.TypeOf(err).Comparable() {
+			if _, ok := visited[err]; ok {
+				return false
+			}
+			visited[err] = struct{}{}
+		}
+		if fn(err) {
+			return true
+		}
+		if w, ok := err.(*withSecondaryError); ok {
+			if walkDeep(w.secondaryError, fn, visited) {
+				return true
+			}
+		}
+		if m, ok := err.(interface{ Unwrap() []error }); ok {
+			for _, c := range m.Unwrap() {
+				if walkDeep(c, fn, visited) {
+					return true
+				}
+			}
+			return false
+		}
+		err = errbase.UnwrapOnce(err)
+	}
+	return false
+}
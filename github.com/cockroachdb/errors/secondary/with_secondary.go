@@ -58,7 +58,13 @@ func (e *withSecondaryError) FormatError(p errbase.Printer) (next error) {
 
 func (e *withSecondaryError) Error() string { return e.cause.Error() }
 func (e *withSecondaryError) Cause() error  { return e.cause }
-func (e *withSecondaryError) Unwrap() error { return e.cause }
+
+// Unwrap implements the Go 1.20 multi-cause interface, so that the
+// standard library's errors.Is / errors.As can reach the secondary
+// error in addition to the primary cause. The library's own cause
+// analysis (UnwrapOnce and the predicates built on top of it) keeps
+// following only the primary cause, via Cause() above.
+func (e *withSecondaryError) Unwrap() []error { return []error{e.cause, e.secondaryError} }
 
 func encodeWithSecondaryError(ctx context.Context, err error) (string, []string, proto.Message) {
 	e := err.(*withSecondaryError)
@@ -0,0 +1,48 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package secondary_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/errors/secondary"
+	"github.com/cockroachdb/errors/testutils"
+)
+
+// TestStdlibIsReachesSecondaries checks that the standard library's
+// errors.Is traverses into secondary errors through the Go 1.20
+// multi-cause interface, even when the combination is deeply nested.
+func TestStdlibIsReachesSecondaries(t *testing.T) {
+	tt := testutils.T{T: t}
+
+	refErr1 := errors.New("one")
+	refErr2 := errors.New("two")
+	refErr3 := errors.New("three")
+	refErr4 := errors.New("four")
+
+	// Build a deep combination: secondaries attached at several
+	// levels, some of them themselves wrapped.
+	err := secondary.WithSecondaryError(refErr1, refErr2)
+	err = fmt.Errorf("context: %w", err)
+	err = secondary.WithSecondaryError(err,
+		secondary.WithSecondaryError(refErr3, fmt.Errorf("deep: %w", refErr4)))
+
+	for _, refErr := range []error{refErr1, refErr2, refErr3, refErr4} {
+		tt.Check(errors.Is(err, refErr))
+	}
+	tt.Check(!errors.Is(err, errors.New("other")))
+}
@@ -25,3 +25,23 @@ func WithSecondaryError(err error, additionalErr error) error {
 func CombineErrors(err, otherErr error) error {
 	return secondary.CombineErrors(err, otherErr)
 }
+
+// GetSecondaryErrors forwards a definition.
+func GetSecondaryErrors(err error, deep bool) []error {
+	return secondary.GetSecondaryErrors(err, deep)
+}
+
+// CombineN forwards a definition.
+func CombineN(errs ...error) error {
+	return secondary.CombineN(errs...)
+}
+
+// WalkDeep forwards a definition.
+func WalkDeep(err error, fn func(err error) bool) bool {
+	return secondary.WalkDeep(err, fn)
+}
+
+// GetAllSafeDetailsDeep forwards a definition.
+func GetAllSafeDetailsDeep(err error) []SafeDetailPayload {
+	return secondary.GetAllSafeDetailsDeep(err)
+}
@@ -14,7 +14,11 @@
 
 package telemetrykeys
 
-import "github.com/cockroachdb/errors/errbase"
+import (
+	"fmt"
+
+	"github.com/cockroachdb/errors/errbase"
+)
 
 // WithTelemetry annotates err with the given telemetry key(s).
 // The telemetry keys must be PII-free.
@@ -32,6 +36,43 @@ func WithTelemetry(err error, keys ...string) error {
 	return &withTelemetry{cause: err, keys: keys}
 }
 
+// maxTelemetryKeyLength bounds the length of keys composed via
+// WithTelemetryf, to keep telemetry cardinality under control.
+const maxTelemetryKeyLength = 64
+
+// WithTelemetryf annotates err with a telemetry key composed by
+// formatting the arguments, e.g. "timeout.%s" with an operation name.
+//
+// Because the key is built at run time, it is validated before being
+// attached: it must be non-empty, at most 64 characters, and contain
+// only lowercase letters, digits and dots. An invalid key is dropped
+// and the original error is returned unchanged, rather than letting
+// unbounded or malformed keys inflate telemetry cardinality.
+func WithTelemetryf(err error, format string, args ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+	key := fmt.Sprintf(format, args...)
+	if !validTelemetryKey(key) {
+		return err
+	}
+	return &withTelemetry{cause: err, keys: []string{key}}
+}
+
+// validTelemetryKey implements the key validation for WithTelemetryf.
+func validTelemetryKey(key string) bool {
+	if key == "" || len(key) > maxTelemetryKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '.' {
+			return false
+		}
+	}
+	return true
+}
+
 // GetTelemetryKeys retrieves the (de-duplicated) set of
 // all telemetry keys present in the direct causal chain
 // of the error. The keys may not be sorted.
@@ -0,0 +1,65 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package telemetrykeys_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/errors/telemetrykeys"
+	"github.com/cockroachdb/errors/testutils"
+)
+
+// TestWithTelemetryfValidation checks that run-time composed keys are
+// validated: a well-formed key is attached, and each flavor of
+// malformed key is dropped, returning the original error unchanged.
+func TestWithTelemetryfValidation(t *testing.T) {
+	tt := testutils.T{T: t}
+
+	baseErr := errors.New("hello")
+
+	err := telemetrykeys.WithTelemetryf(baseErr, "timeout.%s", "read")
+	tt.Assert(err != baseErr)
+	tt.CheckDeepEqual(telemetrykeys.GetTelemetryKeys(err), []string{"timeout.read"})
+
+	testData := []struct {
+		name string
+		key  string
+	}{
+		{"empty", ""},
+		{"uppercase", "Timeout.Read"},
+		{"spaces", "timeout read"},
+		{"punctuation", "timeout/read"},
+		{"missing format argument", "timeout.%s"},
+		{"too long", "timeout." + strings.Repeat("x", 64)},
+	}
+	for _, tc := range testData {
+		tt.Run(tc.name, func(tt testutils.T) {
+			var err error
+			if tc.name == "missing format argument" {
+				// fmt renders the unsatisfied verb as "%!s(MISSING)",
+				// which fails validation like any other bad character.
+				err = telemetrykeys.WithTelemetryf(baseErr, tc.key)
+			} else {
+				err = telemetrykeys.WithTelemetryf(baseErr, "%s", tc.key)
+			}
+			tt.Check(err == baseErr)
+			tt.CheckEqual(len(telemetrykeys.GetTelemetryKeys(err)), 0)
+		})
+	}
+
+	tt.Check(telemetrykeys.WithTelemetryf(nil, "timeout.read") == nil)
+}
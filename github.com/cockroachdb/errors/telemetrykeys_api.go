@@ -19,5 +19,10 @@ import "github.com/cockroachdb/errors/telemetrykeys"
 // WithTelemetry forwards a definition.
 func WithTelemetry(err error, keys ...string) error { return telemetrykeys.WithTelemetry(err, keys...) }
 
+// WithTelemetryf forwards a definition.
+func WithTelemetryf(err error, format string, args ...interface{}) error {
+	return telemetrykeys.WithTelemetryf(err, format, args...)
+}
+
 // GetTelemetryKeys forwards a definition.
 func GetTelemetryKeys(err error) []string { return telemetrykeys.GetTelemetryKeys(err) }
@@ -0,0 +1,65 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+import "github.com/cockroachdb/errors/errbase"
+
+// maxTotalFrames is set via SetMaxTotalFrames().
+var maxTotalFrames = 0
+
+// SetMaxTotalFrames bounds the cumulative number of frames collected
+// by GetAllReportableStackTraces() across all levels of an error.
+// Once the budget is reached, collection stops and the result is
+// marked truncated. This bounds the worst-case payload for
+// pathological, heavily-wrapped error structures while preserving the
+// first-collected (outermost) stacks.
+//
+// The default, 0, applies no limit.
+func SetMaxTotalFrames(n int) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	maxTotalFrames = n
+}
+
+func getMaxTotalFrames() int {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return maxTotalFrames
+}
+
+// GetAllReportableStackTraces collects the reportable stack traces
+// embedded at every level of the error's chain of causes, from
+// outermost to innermost. Levels without a stack trace contribute no
+// entry.
+//
+// The boolean return indicates whether the collection was truncated
+// because the cumulative frame budget was reached (see
+// SetMaxTotalFrames).
+func GetAllReportableStackTraces(err error) (stacks []*ReportableStackTrace, truncated bool) {
+	maxFrames := getMaxTotalFrames()
+	totalFrames := 0
+	for c := err; c != nil; c = errbase.UnwrapOnce(c) {
+		st := GetReportableStackTrace(c)
+		if st == nil {
+			continue
+		}
+		if maxFrames > 0 && totalFrames+len(st.Frames) > maxFrames {
+			return stacks, true
+		}
+		totalFrames += len(st.Frames)
+		stacks = append(stacks, st)
+	}
+	return stacks, false
+}
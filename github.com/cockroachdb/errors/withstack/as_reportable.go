@@ -0,0 +1,38 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+import "github.com/cockroachdb/errors/errbase"
+
+// AsReportableStackTrace is the errors.As-style extractor for stack
+// traces: it walks err's chain of causes from the outermost level
+// inwards and returns the stack trace attached at the outermost level
+// that carries one, with ok set to false when no level does.
+//
+// (ReportableStackTrace is not an error type, so `errors.As` itself
+// cannot target it; this fills that role.)
+//
+// Each level is inspected with GetReportableStackTrace, so the
+// supported stack sources, the frame ordering (oldest first) and the
+// post-processing applied to the result are exactly the same as when
+// extracting from a single level directly.
+func AsReportableStackTrace(err error) (_ *ReportableStackTrace, ok bool) {
+	for c := err; c != nil; c = errbase.UnwrapOnce(c) {
+		if st := GetReportableStackTrace(c); st != nil {
+			return st, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,39 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+import "github.com/cockroachdb/errors/errbase"
+
+// GetCaptureSkip returns the call depth that was requested when the
+// stack trace in err was captured via WithStack() / WithStackDepth():
+// zero identifies the immediate caller of WithStackDepth, a positive
+// value means that many additional frames were skipped.
+//
+// Combined with the stack itself, this tells whether a wrapper helper
+// leaked its own frames into the top of the capture. The outermost
+// capture in the chain wins, mirroring GetReportableStackTrace().
+//
+// The boolean return is false if err carries no stack trace captured
+// by this package, including stacks transferred from another process.
+// This is additive metadata; it does not influence the output of
+// GetReportableStackTrace().
+func GetCaptureSkip(err error) (int, bool) {
+	for c := err; c != nil; c = errbase.UnwrapOnce(c) {
+		if w, ok := c.(*withStack); ok {
+			return w.skip, true
+		}
+	}
+	return 0, false
+}
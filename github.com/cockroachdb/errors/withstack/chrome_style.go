@@ -0,0 +1,52 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FormatStackChromeStyle renders a reportable stack trace in the
+// format used by Chrome DevTools for JavaScript stacks:
+//
+//	at module.function (file:line:col)
+//
+// The frames are rendered newest-first, i.e. in the reverse of the
+// storage order of ReportableStackTrace (which is oldest-first, as
+// Sentry wants it). The column is included only when a column number
+// is available, which for Go frames is usually not the case.
+//
+// This makes it possible to feed Go error stacks to tooling built for
+// JS stacks without reformatting.
+func FormatStackChromeStyle(st *ReportableStackTrace) string {
+	if st == nil {
+		return ""
+	}
+	var buf strings.Builder
+	for i := len(st.Frames) - 1; i >= 0; i-- {
+		f := st.Frames[i]
+		fn := f.Function
+		if f.Module != "" && f.Module != "unknown" {
+			fn = f.Module + "." + fn
+		}
+		fmt.Fprintf(&buf, "    at %s (%s:%d", fn, f.Filename, f.Lineno)
+		if f.Colno != 0 {
+			fmt.Fprintf(&buf, ":%d", f.Colno)
+		}
+		buf.WriteString(")\n")
+	}
+	return buf.String()
+}
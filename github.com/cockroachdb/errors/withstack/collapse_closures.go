@@ -0,0 +1,91 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+import (
+	"fmt"
+	"strings"
+)
+
+// collapseClosureChains is set via CollapseClosureChains().
+var collapseClosureChains = false
+
+// CollapseClosureChains enables or disables the folding of runs of
+// consecutive anonymous-closure frames (e.g. "Handler.func1",
+// "Handler.func1.2") that share the same enclosing function into a
+// single frame labeled with the enclosing function and the closure
+// depth, e.g. "Handler [3 closures]".
+//
+// This declutters stacks from callback-heavy code while keeping the
+// enclosing function visible. The feature is disabled by default.
+func CollapseClosureChains(enable bool) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	collapseClosureChains = enable
+}
+
+func getCollapseClosureChains() bool {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return collapseClosureChains
+}
+
+// closureEnclosingFunc returns the enclosing function of an
+// anonymous-closure name, i.e. the part before the first ".funcN"
+// segment. The boolean return is false when the name is not a
+// closure.
+func closureEnclosingFunc(fn string) (string, bool) {
+	if i := strings.Index(fn, ".func"); i > 0 {
+		return fn[:i], true
+	}
+	return fn, false
+}
+
+// maybeCollapseClosures implements CollapseClosureChains on the
+// result of an extraction.
+func maybeCollapseClosures(st *ReportableStackTrace) *ReportableStackTrace {
+	if !getCollapseClosureChains() || st == nil {
+		return st
+	}
+	var frames []frame
+	for i := 0; i < len(st.Frames); i++ {
+		f := st.Frames[i]
+		base, isClosure := closureEnclosingFunc(f.Function)
+		if !isClosure {
+			frames = append(frames, f)
+			continue
+		}
+		// Extend the run over consecutive closures of the same
+		// enclosing function in the same module.
+		j := i + 1
+		for ; j < len(st.Frames); j++ {
+			next := st.Frames[j]
+			nextBase, nextIsClosure := closureEnclosingFunc(next.Function)
+			if !nextIsClosure || next.Module != f.Module || nextBase != base {
+				break
+			}
+		}
+		if n := j - i; n > 1 {
+			// Collapse the run, keeping the newest frame's location.
+			collapsed := st.Frames[j-1]
+			collapsed.Function = fmt.Sprintf("%s [%d closures]", base, n)
+			frames = append(frames, collapsed)
+			i = j - 1
+		} else {
+			frames = append(frames, f)
+		}
+	}
+	return &ReportableStackTrace{Frames: frames}
+}
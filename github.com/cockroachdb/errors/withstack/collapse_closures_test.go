@@ -0,0 +1,52 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/errors/testutils"
+)
+
+// TestCollapseClosureChains checks that a run of nested anonymous
+// closures is folded into one frame that keeps the enclosing function
+// visible, while single closures and ordinary frames pass through.
+func TestCollapseClosureChains(t *testing.T) {
+	tt := testutils.T{T: t}
+
+	CollapseClosureChains(true)
+	defer CollapseClosureChains(false)
+
+	st := &ReportableStackTrace{Frames: []frame{
+		{Module: "example.com/app", Function: "main"},
+		{Module: "example.com/app", Function: "Handler.func1", Lineno: 10},
+		{Module: "example.com/app", Function: "Handler.func1.2", Lineno: 20},
+		{Module: "example.com/app", Function: "Handler.func1.2.1", Lineno: 30},
+		{Module: "example.com/app", Function: "Finish"},
+		{Module: "example.com/app", Function: "Other.func1", Lineno: 40},
+	}}
+	res := maybeCollapseClosures(st)
+	tt.Assert(res != nil)
+	tt.AssertEqual(len(res.Frames), 4)
+
+	tt.CheckEqual(res.Frames[0].Function, "main")
+	// The run of nested closures collapses into one frame labeled with
+	// the enclosing function, keeping the newest frame's location.
+	tt.CheckEqual(res.Frames[1].Function, "Handler [3 closures]")
+	tt.CheckEqual(res.Frames[1].Lineno, 30)
+	tt.CheckEqual(res.Frames[2].Function, "Finish")
+	// A lone closure is left alone.
+	tt.CheckEqual(res.Frames[3].Function, "Other.func1")
+}
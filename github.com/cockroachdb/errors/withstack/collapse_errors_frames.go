@@ -0,0 +1,68 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+import "strings"
+
+// errorsPkgPrefix identifies the frames generated by this library's
+// own packages.
+const errorsPkgPrefix = "github.com/cockroachdb/errors"
+
+// collapseErrorsPackageFrames is set via CollapseErrorsPackageFrames().
+var collapseErrorsPackageFrames = false
+
+// CollapseErrorsPackageFrames enables or disables the folding of
+// contiguous runs of github.com/cockroachdb/errors frames into a
+// single "[errors]" marker frame during extraction.
+//
+// When an error is created via e.g. errors.Wrapf, several adjacent
+// frames from this library's own packages appear in the captured
+// stack. Folding them into one marker preserves the fact that
+// wrapping happened while decluttering the report.
+//
+// The feature is disabled by default.
+func CollapseErrorsPackageFrames(enable bool) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	collapseErrorsPackageFrames = enable
+}
+
+func getCollapseErrorsPackageFrames() bool {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return collapseErrorsPackageFrames
+}
+
+// maybeCollapseErrorsFrames implements CollapseErrorsPackageFrames on
+// the result of an extraction.
+func maybeCollapseErrorsFrames(st *ReportableStackTrace) *ReportableStackTrace {
+	if !getCollapseErrorsPackageFrames() || st == nil {
+		return st
+	}
+	var frames []frame
+	inRun := false
+	for _, f := range st.Frames {
+		if strings.HasPrefix(f.Module, errorsPkgPrefix) {
+			if !inRun {
+				frames = append(frames, frame{Module: errorsPkgPrefix, Function: "[errors]"})
+				inRun = true
+			}
+			continue
+		}
+		inRun = false
+		frames = append(frames, f)
+	}
+	return &ReportableStackTrace{Frames: frames}
+}
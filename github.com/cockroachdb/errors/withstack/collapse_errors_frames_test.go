@@ -0,0 +1,52 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/errors/testutils"
+)
+
+// TestCollapseErrorsFrames checks that contiguous runs of this
+// library's own frames - as captured when an error is wrapped several
+// times - are folded into a single "[errors]" marker frame, while the
+// user frames around them survive unchanged.
+func TestCollapseErrorsFrames(t *testing.T) {
+	tt := testutils.T{T: t}
+
+	CollapseErrorsPackageFrames(true)
+	defer CollapseErrorsPackageFrames(false)
+
+	st := &ReportableStackTrace{Frames: []frame{
+		{Module: "runtime", Function: "main"},
+		{Module: "example.com/app", Function: "Outer"},
+		{Module: errorsPkgPrefix + "/errutil", Function: "Wrapf"},
+		{Module: errorsPkgPrefix + "/withstack", Function: "WithStackDepth"},
+		{Module: "example.com/app", Function: "Inner"},
+	}}
+	res := maybeCollapseErrorsFrames(st)
+	tt.Assert(res != nil)
+	tt.AssertEqual(len(res.Frames), 4)
+	tt.CheckEqual(res.Frames[0].Function, "main")
+	tt.CheckEqual(res.Frames[1].Function, "Outer")
+	tt.CheckEqual(res.Frames[2].Function, "[errors]")
+	tt.CheckEqual(res.Frames[2].Module, errorsPkgPrefix)
+	tt.CheckEqual(res.Frames[3].Function, "Inner")
+
+	// Disabled: the stack passes through untouched.
+	CollapseErrorsPackageFrames(false)
+	tt.AssertEqual(len(maybeCollapseErrorsFrames(st).Frames), 5)
+}
@@ -0,0 +1,79 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+import (
+	"fmt"
+
+	"github.com/cockroachdb/errors/errbase"
+)
+
+// GetCombinedReportableStackTrace collects all the stack traces
+// embedded at the various levels of the given error chain and merges
+// them into a single trace without repeated frames.
+//
+// When an error is wrapped with WithStack at several layers, the
+// captures overlap: the frames below the point where the wrapping
+// happened are identical in every capture. GetReportableStackTrace
+// sidesteps the duplication by returning only one stack; reporting
+// paths that want all the captured information can use this function
+// instead to get every distinct frame exactly once.
+//
+// The merge starts from the innermost (closest to the error's origin)
+// stack and, for each stack further out, appends only the frames that
+// extend beyond the portion already seen; a stack that is entirely a
+// repeat of already-seen frames contributes nothing. The result keeps
+// the oldest frame first, like GetReportableStackTrace.
+//
+// The result is nil when no level of the chain carries a stack trace.
+func GetCombinedReportableStackTrace(err error) *ReportableStackTrace {
+	// Collect the stacks, outermost first.
+	var stacks []*ReportableStackTrace
+	for c := err; c != nil; c = errbase.UnwrapOnce(c) {
+		if st := getReportableStackTrace(c); st != nil && len(st.Frames) > 0 {
+			stacks = append(stacks, st)
+		}
+	}
+	if len(stacks) == 0 {
+		return nil
+	}
+
+	// Merge innermost first: the innermost capture holds the oldest
+	// frames, and each capture further out was taken later, as the
+	// error propagated up, so its novel frames belong on the newer
+	// side of the result.
+	var combined []frame
+	var seen []string
+	for i := len(stacks) - 1; i >= 0; i-- {
+		fs := stacks[i].Frames
+		keys := make([]string, len(fs))
+		for j, f := range fs {
+			keys[j] = frameKey(f)
+		}
+		shared := 0
+		for shared < len(keys) && shared < len(seen) && keys[shared] == seen[shared] {
+			shared++
+		}
+		combined = append(combined, fs[shared:]...)
+		seen = append(seen, keys[shared:]...)
+	}
+	return &ReportableStackTrace{Frames: combined}
+}
+
+// frameKey computes the identity of a frame for the purpose of
+// duplicate elimination.
+func frameKey(f frame) string {
+	return fmt.Sprintf("%s\x00%s\x00%d", FullyQualifiedFunction(f), f.Filename, f.Lineno)
+}
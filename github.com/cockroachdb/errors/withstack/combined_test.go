@@ -0,0 +1,101 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cockroachdb/errors/testutils"
+)
+
+//go:noinline
+func combinedInner() error {
+	return WithStack(errors.New("hello"))
+}
+
+//go:noinline
+func combinedMid() error {
+	return WithStack(combinedInner())
+}
+
+//go:noinline
+func combinedOuter() error {
+	return WithStack(combinedMid())
+}
+
+// TestCombinedStackTrace checks that with three nested WithStack
+// calls, the merged trace contains the frames of all three capture
+// sites, without repeating the frames the captures share.
+func TestCombinedStackTrace(t *testing.T) {
+	tt := testutils.T{T: t}
+
+	err := combinedOuter()
+	st := GetCombinedReportableStackTrace(err)
+	tt.Assert(st != nil)
+
+	counts := map[string]int{}
+	keys := map[string]int{}
+	for _, f := range st.Frames {
+		counts[f.Function]++
+		keys[frameKey(f)]++
+	}
+	tt.Check(counts["combinedInner"] >= 1)
+	tt.Check(counts["combinedMid"] >= 1)
+	tt.Check(counts["combinedOuter"] >= 1)
+
+	// Every distinct frame appears exactly once.
+	for k, n := range keys {
+		if n != 1 {
+			t.Errorf("frame %q appears %d times", k, n)
+		}
+	}
+}
+
+// TestFindFrameAt checks that FindFrameAt locates the frame nearest a
+// given source position in a trace merged from three nested WithStack
+// calls, matching the file by suffix.
+func TestFindFrameAt(t *testing.T) {
+	tt := testutils.T{T: t}
+
+	err := combinedOuter()
+	st := GetCombinedReportableStackTrace(err)
+	tt.Assert(st != nil)
+
+	var innerFrame Frame
+	for _, f := range st.Frames {
+		if f.Function == "combinedInner" {
+			innerFrame = f
+		}
+	}
+	tt.Assert(innerFrame.Lineno > 0)
+
+	// An exact position designates the capturing frame; an abbreviated
+	// file path matches by suffix.
+	f, ok := FindFrameAt(st, "combined_test.go", innerFrame.Lineno)
+	tt.Assert(ok)
+	tt.CheckEqual(f.Function, "combinedInner")
+
+	// A nearby line still resolves to the nearest frame in this file.
+	f, ok = FindFrameAt(st, "combined_test.go", innerFrame.Lineno-1)
+	tt.Assert(ok)
+	tt.CheckEqual(f.Filename, innerFrame.Filename)
+
+	// An unknown file finds nothing.
+	_, ok = FindFrameAt(st, "no_such_file.go", 1)
+	tt.Check(!ok)
+	_, ok = FindFrameAt(nil, "combined_test.go", 1)
+	tt.Check(!ok)
+}
@@ -0,0 +1,30 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+// GetReportableStackTraceIf extracts the stack trace embedded in the
+// given error (like GetReportableStackTrace), but only when the
+// given predicate accepts the error; otherwise it returns nil
+// immediately, without performing any extraction work.
+//
+// While the gating could be done by the caller, centralizing it here
+// guarantees that predicate-rejected errors never pay the extraction
+// cost (parsing, symbolication, post-processing) at all.
+func GetReportableStackTraceIf(err error, pred func(error) bool) *ReportableStackTrace {
+	if pred != nil && !pred(err) {
+		return nil
+	}
+	return GetReportableStackTrace(err)
+}
@@ -0,0 +1,51 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/errors/errbase"
+	"github.com/cockroachdb/errors/testutils"
+)
+
+// countingStackError counts how many times its stack trace is
+// consulted by an extraction.
+type countingStackError struct {
+	calls int
+}
+
+func (e *countingStackError) Error() string { return "counted" }
+func (e *countingStackError) StackTrace() errbase.StackTrace {
+	e.calls++
+	return nil
+}
+
+// TestGetReportableStackTraceIf checks that a rejecting predicate
+// short-circuits the extraction entirely: the error's stack trace is
+// never consulted.
+func TestGetReportableStackTraceIf(t *testing.T) {
+	tt := testutils.T{T: t}
+
+	err := &countingStackError{}
+	tt.Check(GetReportableStackTraceIf(err, func(error) bool { return false }) == nil)
+	tt.CheckEqual(err.calls, 0)
+
+	// An accepting predicate (and a nil one) performs the extraction.
+	_ = GetReportableStackTraceIf(err, func(error) bool { return true })
+	tt.CheckEqual(err.calls, 1)
+	_ = GetReportableStackTraceIf(err, nil)
+	tt.CheckEqual(err.calls, 2)
+}
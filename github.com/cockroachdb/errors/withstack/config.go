@@ -0,0 +1,30 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+import "sync"
+
+// configMu protects the package-level configuration knobs of this
+// package: the comparators, resolvers, annotators and boolean toggles
+// installed by the various exported Set*/enable functions. Every
+// setter takes the write lock and every internal read goes through a
+// small accessor taking the read lock, so the knobs can be adjusted
+// while other goroutines are capturing or processing stack traces.
+//
+// The lock makes individual accesses safe; it does not make a
+// reconfiguration of several knobs atomic with respect to a
+// concurrent extraction. Callers that need a consistent combination
+// of settings should still configure the package before use.
+var configMu sync.RWMutex
@@ -0,0 +1,65 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+// TestConcurrentConfiguration exercises the configuration setters
+// concurrently with stack capture and extraction. It has no
+// assertions of its own: it exists to let the race detector verify
+// that the knobs are properly synchronized.
+func TestConcurrentConfiguration(t *testing.T) {
+	defer func() {
+		// Restore the defaults for the other tests.
+		SetMaxTotalFrames(0)
+		CollapseClosureChains(false)
+		SetFrameComparator(nil)
+		SetInAppPredicate(nil)
+		RedactLineNumbers(false)
+		SetMaxFunctionNameLength(0)
+		CaptureTimestamps(false)
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				SetMaxTotalFrames(j)
+				CollapseClosureChains(j%2 == 0)
+				SetFrameComparator(FramesEqualDefault)
+				SetInAppPredicate(func(module, filename string) bool { return true })
+				RedactLineNumbers(j%2 == 1)
+				SetMaxFunctionNameLength(100)
+				CaptureTimestamps(j%2 == 0)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				err := WithStack(errors.New("hello"))
+				_ = GetReportableStackTrace(err)
+				_, _ = GetAllReportableStackTraces(err)
+				_ = GetStackFrames(err)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,40 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+// StackContainsFunction reports whether the call path recorded in the
+// error's stack trace went through the named function. The name is
+// matched against both the bare function name and the fully-qualified
+// "module.function" form.
+//
+// This is a general primitive to answer "did this error's call path
+// go through function X". For example, passing
+// "sync.(*Mutex).Lock" detects errors whose stack was captured under
+// a lock-contention path, which helps flag errors that originated
+// while a known mutex was being acquired.
+//
+// The function returns false when no stack trace is available.
+func StackContainsFunction(err error, funcName string) bool {
+	st := GetReportableStackTrace(err)
+	if st == nil {
+		return false
+	}
+	for _, f := range st.Frames {
+		if f.Function == funcName || f.Module+"."+f.Function == funcName {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/cockroachdb/sentry-go"
+)
+
+// Frame aliases the frame type of the sentry package. This is the
+// element type of ReportableStackTrace.Frames.
+type Frame = sentry.Frame
+
+// FullyQualifiedFunction returns the canonical identifier of the
+// function in the given frame: the module (package path) and function
+// name joined with a dot, e.g. "example.com/pkg.Handle". Two
+// same-named functions in different packages thus get distinct
+// identifiers; the fingerprinting and deduplication helpers in this
+// package use this instead of the bare function name.
+func FullyQualifiedFunction(f Frame) string {
+	if f.Module == "" || f.Module == "unknown" {
+		return f.Function
+	}
+	return f.Module + "." + f.Function
+}
+
+// FrameEqualFunc determines whether two reportable stack frames are
+// to be considered equal. It is consulted by the deduplication and
+// collapse helpers in this package, so that callers can choose their
+// own definition of frame equality (e.g. ignoring line numbers, or
+// comparing modules only).
+type FrameEqualFunc func(a, b Frame) bool
+
+// FramesEqualDefault is the default frame comparator: two frames are
+// equal when their fully qualified function (see
+// FullyQualifiedFunction) and line number both match.
+func FramesEqualDefault(a, b Frame) bool {
+	return FullyQualifiedFunction(a) == FullyQualifiedFunction(b) && a.Lineno == b.Lineno
+}
+
+// frameEqual is the comparator currently in effect. See
+// SetFrameComparator().
+var frameEqual FrameEqualFunc = FramesEqualDefault
+
+// SetFrameComparator overrides the frame comparator used by the
+// deduplication and collapse helpers in this package. Passing nil
+// restores FramesEqualDefault.
+func SetFrameComparator(eq FrameEqualFunc) {
+	if eq == nil {
+		eq = FramesEqualDefault
+	}
+	configMu.Lock()
+	defer configMu.Unlock()
+	frameEqual = eq
+}
+
+func getFrameEqual() FrameEqualFunc {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return frameEqual
+}
+
+// CompareFrames defines the total order used by the sorting and
+// fingerprinting helpers in this package. It returns a negative
+// value, zero or a positive value if a sorts before, equal to or
+// after b.
+//
+// Frames compare by module first, then function. Frames equal on
+// both (which typically hash identically in fingerprints) are ordered
+// by the documented tiebreaker: line number first, then file path.
+// The resulting order is total and deterministic, so fingerprints
+// computed from sorted frames are identical across machines and runs
+// regardless of the input order.
+func CompareFrames(a, b Frame) int {
+	if c := strings.Compare(a.Module, b.Module); c != 0 {
+		return c
+	}
+	if c := strings.Compare(a.Function, b.Function); c != 0 {
+		return c
+	}
+	// Tiebreaker for equal-hash frames: line number, then file path.
+	if a.Lineno != b.Lineno {
+		if a.Lineno < b.Lineno {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(a.Filename, b.Filename)
+}
+
+// SortFrames sorts the given frames in the deterministic order
+// defined by CompareFrames. The sort is stable.
+func SortFrames(frames []Frame) {
+	sort.SliceStable(frames, func(i, j int) bool {
+		return CompareFrames(frames[i], frames[j]) < 0
+	})
+}
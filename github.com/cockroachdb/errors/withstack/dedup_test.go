@@ -0,0 +1,81 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/cockroachdb/errors/testutils"
+)
+
+// TestFullyQualifiedFunction checks that same-named functions in
+// different modules get distinct identifiers, so that fingerprints
+// built from them cannot collide.
+func TestFullyQualifiedFunction(t *testing.T) {
+	tt := testutils.T{T: t}
+
+	a := Frame{Module: "example.com/alpha", Function: "Handle"}
+	b := Frame{Module: "example.com/beta", Function: "Handle"}
+	tt.CheckEqual(FullyQualifiedFunction(a), "example.com/alpha.Handle")
+	tt.CheckEqual(FullyQualifiedFunction(b), "example.com/beta.Handle")
+	tt.Check(FullyQualifiedFunction(a) != FullyQualifiedFunction(b))
+
+	// Frames without a usable module fall back to the bare name.
+	tt.CheckEqual(FullyQualifiedFunction(Frame{Function: "Handle"}), "Handle")
+	tt.CheckEqual(FullyQualifiedFunction(Frame{Module: "unknown", Function: "Handle"}), "Handle")
+}
+
+// TestSortFramesDeterministic checks that SortFrames produces the
+// same total order regardless of the input order, including for
+// frames that differ only in the documented tiebreakers (line number,
+// then file path).
+func TestSortFramesDeterministic(t *testing.T) {
+	tt := testutils.T{T: t}
+
+	base := []Frame{
+		{Module: "example.com/a", Function: "F", Lineno: 10, Filename: "a.go"},
+		{Module: "example.com/a", Function: "F", Lineno: 10, Filename: "b.go"},
+		{Module: "example.com/a", Function: "F", Lineno: 20, Filename: "a.go"},
+		{Module: "example.com/a", Function: "G", Lineno: 5, Filename: "a.go"},
+		{Module: "example.com/b", Function: "F", Lineno: 1, Filename: "c.go"},
+		{Module: "runtime", Function: "main", Lineno: 250, Filename: "proc.go"},
+	}
+
+	var reference []Frame
+	rng := rand.New(rand.NewSource(1))
+	for run := 0; run < 20; run++ {
+		frames := append([]Frame(nil), base...)
+		rng.Shuffle(len(frames), func(i, j int) {
+			frames[i], frames[j] = frames[j], frames[i]
+		})
+		SortFrames(frames)
+		if reference == nil {
+			reference = frames
+			continue
+		}
+		tt.Assert(reflect.DeepEqual(frames, reference))
+	}
+
+	// Spot-check the tiebreakers on the reference order.
+	tt.CheckEqual(reference[0].Filename, "a.go")
+	tt.CheckEqual(reference[0].Lineno, 10)
+	tt.CheckEqual(reference[1].Filename, "b.go")
+	tt.CheckEqual(reference[2].Lineno, 20)
+	tt.CheckEqual(reference[3].Function, "G")
+	tt.CheckEqual(reference[4].Module, "example.com/b")
+	tt.CheckEqual(reference[5].Module, "runtime")
+}
@@ -0,0 +1,107 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// StackTraceDigest computes a stable hex digest of a reportable stack
+// trace, derived from each frame's fully qualified function (see
+// FullyQualifiedFunction) and line number in stack order.
+// Structurally identical stacks produce identical digests across
+// processes and runs. The empty string is returned for a nil stack.
+func StackTraceDigest(st *ReportableStackTrace) string {
+	if st == nil {
+		return ""
+	}
+	h := sha256.New()
+	for _, f := range st.Frames {
+		fmt.Fprintf(h, "%s\x00%d\x0a", FullyQualifiedFunction(f), f.Lineno)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// ReportDeduper is an in-process gate that suppresses repeated
+// reports of the same error: the same stack (as identified by
+// StackTraceDigest) is let through at most once per TTL window. The
+// seen-set is bounded: once full, the least recently seen digests are
+// evicted first.
+//
+// A ReportDeduper is safe for concurrent use.
+type ReportDeduper struct {
+	mu         sync.Mutex
+	maxEntries int
+	ttl        time.Duration
+	entries    map[string]*list.Element
+	order      *list.List // front = most recently seen
+}
+
+// deduperEntry is the payload of one LRU list element.
+type deduperEntry struct {
+	digest string
+	when   time.Time
+}
+
+// NewReportDeduper creates a ReportDeduper retaining at most
+// maxEntries digests, each suppressing duplicates for the given TTL.
+// A zero TTL suppresses duplicates for as long as the digest stays in
+// the bounded seen-set.
+func NewReportDeduper(maxEntries int, ttl time.Duration) *ReportDeduper {
+	return &ReportDeduper{
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		entries:    map[string]*list.Element{},
+		order:      list.New(),
+	}
+}
+
+// ShouldReport extracts the stack trace embedded in err and reports
+// whether it has not been seen within the TTL window. The first call
+// for a given stack returns true and records it; subsequent calls
+// within the window return false. Errors without a stack trace cannot
+// be deduplicated and always return true.
+func (d *ReportDeduper) ShouldReport(err error) bool {
+	digest := StackTraceDigest(GetReportableStackTrace(err))
+	if digest == "" {
+		return true
+	}
+	now := time.Now()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if elem, ok := d.entries[digest]; ok {
+		e := elem.Value.(*deduperEntry)
+		if d.ttl == 0 || now.Sub(e.when) < d.ttl {
+			d.order.MoveToFront(elem)
+			return false
+		}
+		// Expired: refresh and report again.
+		e.when = now
+		d.order.MoveToFront(elem)
+		return true
+	}
+	d.entries[digest] = d.order.PushFront(&deduperEntry{digest: digest, when: now})
+	for d.maxEntries > 0 && d.order.Len() > d.maxEntries {
+		oldest := d.order.Back()
+		d.order.Remove(oldest)
+		delete(d.entries, oldest.Value.(*deduperEntry).digest)
+	}
+	return true
+}
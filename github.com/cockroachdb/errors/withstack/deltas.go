@@ -0,0 +1,48 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RenderStackWithDeltas renders a reportable stack trace, newest
+// frame first, expressing each frame's line number relative to the
+// previous frame seen in the same file: the first occurrence of a
+// file shows the absolute line number, subsequent occurrences show a
+// signed delta ("+12", "-3").
+//
+// This is a pure formatting helper for compact, diff-like terminal
+// renderings; it does not alter the underlying stack data.
+func RenderStackWithDeltas(st *ReportableStackTrace) string {
+	if st == nil {
+		return ""
+	}
+	lastLine := map[string]int{}
+	var buf strings.Builder
+	for i := len(st.Frames) - 1; i >= 0; i-- {
+		f := st.Frames[i]
+		var loc string
+		if prev, seen := lastLine[f.Filename]; seen {
+			loc = fmt.Sprintf("%+d", f.Lineno-prev)
+		} else {
+			loc = fmt.Sprintf("%d", f.Lineno)
+		}
+		lastLine[f.Filename] = f.Lineno
+		fmt.Fprintf(&buf, "%s:%s: in %s()\n", f.Filename, loc, f.Function)
+	}
+	return buf.String()
+}
@@ -0,0 +1,45 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+// symbolDemangler is applied to every Function name during frame
+// construction. See SetSymbolDemangler(). The default is the
+// identity.
+var symbolDemangler func(raw string) string
+
+// SetSymbolDemangler installs a hook applied to the Function name of
+// every frame during stack trace extraction. This makes it possible
+// to plug in a demangler for C/cgo symbols (or e.g. Rust symbols from
+// embedded libraries) which otherwise appear with unreadable mangled
+// names, without this package depending on any particular demangler.
+//
+// The default (and the effect of passing nil) is to leave the names
+// unchanged.
+func SetSymbolDemangler(fn func(raw string) string) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	symbolDemangler = fn
+}
+
+// demangle applies the configured symbol demangler, if any.
+func demangle(fnName string) string {
+	configMu.RLock()
+	fn := symbolDemangler
+	configMu.RUnlock()
+	if fn == nil {
+		return fnName
+	}
+	return fn(fnName)
+}
@@ -0,0 +1,98 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+import (
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+// FrameDependency pairs one extracted stack frame with the build-info
+// dependency it was resolved to.
+type FrameDependency struct {
+	// Frame is the extracted frame.
+	Frame Frame
+	// Dependency is the module path of the dependency containing the
+	// frame's package, per debug.ReadBuildInfo. It is empty for frames
+	// in the main module or the standard library.
+	Dependency string
+	// Version is the version of that dependency, empty when Dependency
+	// is empty.
+	Version string
+}
+
+// GetFramesWithDependencies extracts the stack trace embedded in the
+// given error (like GetReportableStackTrace) and resolves each frame's
+// package against the dependency list recorded in the binary's build
+// info, so that errors can be attributed to specific dependency
+// versions. Frames in the main module or the standard library get an
+// empty dependency and version. The frames are returned oldest first.
+//
+// The result is nil when no stack trace is available. When the binary
+// carries no build info (e.g. built outside module mode), the frames
+// are returned with empty dependency information.
+func GetFramesWithDependencies(err error) []FrameDependency {
+	st := GetReportableStackTrace(err)
+	if st == nil {
+		return nil
+	}
+	deps := buildDependencies()
+	result := make([]FrameDependency, len(st.Frames))
+	for i, f := range st.Frames {
+		module := f.Module
+		if f.Symbol != "" {
+			// The Symbol field carries the raw, untruncated symbol;
+			// re-derive the complete module path from it.
+			module, _ = functionName(f.Symbol)
+		}
+		result[i] = FrameDependency{Frame: f}
+		if dep, ok := lookupDependency(deps, module); ok {
+			result[i].Dependency = dep.Path
+			result[i].Version = dep.Version
+		}
+	}
+	return result
+}
+
+// lookupDependency finds the dependency whose module path is a prefix
+// of the given package path, preferring the longest match (a
+// dependency may have nested modules).
+func lookupDependency(deps []*debug.Module, pkgPath string) (*debug.Module, bool) {
+	var best *debug.Module
+	for _, dep := range deps {
+		if pkgPath != dep.Path && !strings.HasPrefix(pkgPath, dep.Path+"/") {
+			continue
+		}
+		if best == nil || len(dep.Path) > len(best.Path) {
+			best = dep
+		}
+	}
+	return best, best != nil
+}
+
+var buildDepsOnce sync.Once
+var buildDeps []*debug.Module
+
+// buildDependencies retrieves (once) the dependency list from the
+// binary's build info.
+func buildDependencies() []*debug.Module {
+	buildDepsOnce.Do(func() {
+		if info, ok := debug.ReadBuildInfo(); ok {
+			buildDeps = info.Deps
+		}
+	})
+	return buildDeps
+}
@@ -0,0 +1,113 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"reflect"
+)
+
+// stackDiffMagic heads every payload produced by DiffStacksBinary, so
+// that ApplyStackDiff can reject foreign data.
+var stackDiffMagic = []byte("esd1")
+
+// DiffStacksBinary encodes the difference between two stack traces as
+// a compact binary payload: the frames of next that are shared with
+// base (as a common leading and trailing run) are stored as counts
+// only, and only the frames in between are stored in full. For a
+// high-volume, slowly-evolving error this lets a store keep the first
+// stack fully and each later occurrence as a small diff.
+//
+// The payload is only meaningful against the exact base it was
+// computed from; apply it with ApplyStackDiff. Either argument may be
+// nil, which is treated as a stack with no frames.
+func DiffStacksBinary(base, next *ReportableStackTrace) []byte {
+	var baseFrames, nextFrames []frame
+	if base != nil {
+		baseFrames = base.Frames
+	}
+	if next != nil {
+		nextFrames = next.Frames
+	}
+
+	// Find the longest common leading run...
+	prefix := 0
+	for prefix < len(baseFrames) && prefix < len(nextFrames) &&
+		reflect.DeepEqual(baseFrames[prefix], nextFrames[prefix]) {
+		prefix++
+	}
+	// ... and the longest common trailing run after it.
+	suffix := 0
+	for suffix < len(baseFrames)-prefix && suffix < len(nextFrames)-prefix &&
+		reflect.DeepEqual(
+			baseFrames[len(baseFrames)-1-suffix], nextFrames[len(nextFrames)-1-suffix]) {
+		suffix++
+	}
+
+	inserted, err := json.Marshal(nextFrames[prefix : len(nextFrames)-suffix])
+	if err != nil {
+		// Frames with unmarshalable Vars degrade to storing nothing for
+		// the middle section; ApplyStackDiff yields only the shared
+		// frames in that case.
+		inserted = []byte("[]")
+	}
+
+	buf := make([]byte, 0, len(stackDiffMagic)+2*binary.MaxVarintLen64+len(inserted))
+	buf = append(buf, stackDiffMagic...)
+	var tmp [binary.MaxVarintLen64]byte
+	buf = append(buf, tmp[:binary.PutUvarint(tmp[:], uint64(prefix))]...)
+	buf = append(buf, tmp[:binary.PutUvarint(tmp[:], uint64(suffix))]...)
+	buf = append(buf, inserted...)
+	return buf
+}
+
+// ApplyStackDiff reconstructs a stack trace from a base stack and a
+// payload produced by DiffStacksBinary against that same base. The
+// result is nil when the payload is malformed or was not produced by
+// DiffStacksBinary.
+func ApplyStackDiff(base *ReportableStackTrace, diff []byte) *ReportableStackTrace {
+	if len(diff) < len(stackDiffMagic) || string(diff[:len(stackDiffMagic)]) != string(stackDiffMagic) {
+		return nil
+	}
+	rest := diff[len(stackDiffMagic):]
+	prefix, n := binary.Uvarint(rest)
+	if n <= 0 {
+		return nil
+	}
+	rest = rest[n:]
+	suffix, n := binary.Uvarint(rest)
+	if n <= 0 {
+		return nil
+	}
+	rest = rest[n:]
+	var inserted []frame
+	if err := json.Unmarshal(rest, &inserted); err != nil {
+		return nil
+	}
+
+	var baseFrames []frame
+	if base != nil {
+		baseFrames = base.Frames
+	}
+	if prefix+suffix > uint64(len(baseFrames)) {
+		return nil
+	}
+	frames := make([]frame, 0, int(prefix)+len(inserted)+int(suffix))
+	frames = append(frames, baseFrames[:prefix]...)
+	frames = append(frames, inserted...)
+	frames = append(frames, baseFrames[uint64(len(baseFrames))-suffix:]...)
+	return &ReportableStackTrace{Frames: frames}
+}
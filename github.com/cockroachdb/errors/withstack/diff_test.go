@@ -0,0 +1,76 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/cockroachdb/errors/testutils"
+)
+
+// TestStackDiffRoundTrip checks the fundamental property of the
+// binary diff format: apply(base, diff(base, next)) == next, for
+// various shapes of shared and divergent frames.
+func TestStackDiffRoundTrip(t *testing.T) {
+	tt := testutils.T{T: t}
+
+	mk := func(fns ...string) *ReportableStackTrace {
+		var frames []frame
+		for i, fn := range fns {
+			frames = append(frames, frame{
+				Module: "example.com/app", Function: fn, Lineno: 10 * (i + 1),
+			})
+		}
+		return &ReportableStackTrace{Frames: frames}
+	}
+
+	testData := []struct {
+		name       string
+		base, next *ReportableStackTrace
+	}{
+		{"identical", mk("a", "b", "c"), mk("a", "b", "c")},
+		{"middle changed", mk("a", "b", "c"), mk("a", "x", "c")},
+		{"frames added", mk("a", "c"), mk("a", "b1", "b2", "c")},
+		{"frames removed", mk("a", "b1", "b2", "c"), mk("a", "c")},
+		{"disjoint", mk("a", "b"), mk("x", "y")},
+		{"nil base", nil, mk("a", "b")},
+		{"nil next", mk("a", "b"), nil},
+	}
+	for _, tc := range testData {
+		tt.Run(tc.name, func(tt testutils.T) {
+			diff := DiffStacksBinary(tc.base, tc.next)
+			res := ApplyStackDiff(tc.base, diff)
+			tt.Assert(res != nil)
+			var want []frame
+			if tc.next != nil {
+				want = tc.next.Frames
+			}
+			if len(want) == 0 {
+				tt.CheckEqual(len(res.Frames), 0)
+			} else {
+				tt.Check(reflect.DeepEqual(res.Frames, want))
+			}
+		})
+	}
+
+	// Foreign or truncated payloads are rejected.
+	tt.Check(ApplyStackDiff(mk("a"), []byte("bogus payload")) == nil)
+	tt.Check(ApplyStackDiff(mk("a"), []byte("esd")) == nil)
+	// A diff applied against the wrong base cannot invent frames: the
+	// shared counts must fit the base.
+	diff := DiffStacksBinary(mk("a", "b", "c"), mk("a", "b", "c"))
+	tt.Check(ApplyStackDiff(mk("a"), diff) == nil)
+}
@@ -0,0 +1,55 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+// APMStackFrame mirrors one entry of the "stacktrace" array in an
+// Elastic APM error document.
+type APMStackFrame struct {
+	Module   string `json:"module"`
+	Function string `json:"function"`
+	Filename string `json:"filename"`
+	Lineno   int    `json:"lineno"`
+	// LibraryFrame is the inverse of the in-app classification: true
+	// for frames considered library (non-application) code.
+	LibraryFrame bool `json:"library_frame"`
+}
+
+// GetElasticAPMStackTrace extracts the stack trace embedded in the
+// given error in the shape expected by Elastic APM error documents.
+//
+// The extraction reuses GetReportableStackTrace(), so the frame set
+// matches the Sentry reporting path exactly; only the field mapping
+// and the ordering differ: the frames are returned newest-first, per
+// Elastic's convention. The result is nil when no stack trace is
+// available.
+func GetElasticAPMStackTrace(err error) []APMStackFrame {
+	st := GetReportableStackTrace(err)
+	if st == nil {
+		return nil
+	}
+	frames := make([]APMStackFrame, 0, len(st.Frames))
+	// The frames are stored oldest-first; emit them newest-first.
+	for i := len(st.Frames) - 1; i >= 0; i-- {
+		f := st.Frames[i]
+		frames = append(frames, APMStackFrame{
+			Module:       f.Module,
+			Function:     f.Function,
+			Filename:     f.Filename,
+			Lineno:       f.Lineno,
+			LibraryFrame: !f.InApp,
+		})
+	}
+	return frames
+}
@@ -0,0 +1,50 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+// frameEnricher is applied to every frame after basic resolution. See
+// SetFrameEnricher(). The default is the identity.
+var frameEnricher func(Frame) Frame
+
+// SetFrameEnricher installs a hook invoked on every frame after its
+// basic resolution (file/line/function extraction and demangling, see
+// SetSymbolDemangler). This makes it possible to overlay details from
+// an external, DWARF-like source map — e.g. column numbers or
+// inlined call chains that the stripped runtime information lacks —
+// onto each frame.
+//
+// The hooks compose: the demangler normalizes the symbol name, then
+// the enricher adds richness on top of the resolved frame.
+//
+// The default (and the effect of passing nil) is to leave the frames
+// unchanged.
+func SetFrameEnricher(fn func(Frame) Frame) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	frameEnricher = fn
+}
+
+// enrich applies the configured source context capture (see
+// SetSourceContextResolver) and frame enricher, if any.
+func enrich(f Frame) Frame {
+	f = addSourceContext(f)
+	configMu.RLock()
+	fn := frameEnricher
+	configMu.RUnlock()
+	if fn == nil {
+		return f
+	}
+	return fn(f)
+}
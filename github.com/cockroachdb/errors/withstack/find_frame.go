@@ -0,0 +1,63 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+import "strings"
+
+// FindFrameAt searches the given stack trace for the frame that best
+// encloses the given source position, e.g. a file:line pair lifted
+// from an external crash log. A frame is a candidate when the given
+// file matches its file path by suffix (so both full and abbreviated
+// paths work); among candidates, the frame whose line number is
+// nearest the given line wins, with the newest such frame preferred on
+// ties.
+//
+// The boolean return is false when no frame in the stack refers to the
+// given file (or the stack is nil).
+func FindFrameAt(st *ReportableStackTrace, file string, line int) (Frame, bool) {
+	if st == nil {
+		return Frame{}, false
+	}
+	var best Frame
+	bestDist := -1
+	// The frames are oldest first; walk newest first so that the
+	// newest frame wins on equal distance.
+	for i := len(st.Frames) - 1; i >= 0; i-- {
+		f := st.Frames[i]
+		if !fileMatchesSuffix(f.Filename, file) && !fileMatchesSuffix(f.AbsPath, file) {
+			continue
+		}
+		dist := f.Lineno - line
+		if dist < 0 {
+			dist = -dist
+		}
+		if bestDist == -1 || dist < bestDist {
+			best, bestDist = f, dist
+		}
+	}
+	return best, bestDist != -1
+}
+
+// fileMatchesSuffix reports whether the query path designates the
+// frame's file: either path is a whole-component suffix of the other.
+func fileMatchesSuffix(framePath, query string) bool {
+	if framePath == "" || query == "" {
+		return false
+	}
+	if framePath == query {
+		return true
+	}
+	return strings.HasSuffix(framePath, "/"+query) || strings.HasSuffix(query, "/"+framePath)
+}
@@ -0,0 +1,52 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+import (
+	"crypto/sha1"
+	"fmt"
+)
+
+// frameUUIDNamespace is the fixed UUID namespace under which frame
+// UUIDs are derived (see FrameUUID). It was generated once, randomly,
+// and must never change: the stability of the derived UUIDs depends
+// on it.
+var frameUUIDNamespace = [16]byte{
+	0x8a, 0x3d, 0x1f, 0x6e, 0x42, 0x9b, 0x4c, 0x51,
+	0xb7, 0x20, 0xd4, 0x5a, 0x0c, 0x7e, 0x93, 0x16,
+}
+
+// FrameUUID returns a deterministic, name-based (version 5) UUID for
+// the given frame within the given build: it is derived from the
+// frame's fully qualified function (see FullyQualifiedFunction) and
+// line number together with the build ID. Structurally identical frames in the same build therefore map
+// to the same UUID, across processes and across time.
+//
+// This is intended as a stable key for external per-frame metadata,
+// e.g. persisting engineer-attached annotations across occurrences of
+// the same error in the same release.
+func FrameUUID(f Frame, buildID string) string {
+	h := sha1.New()
+	h.Write(frameUUIDNamespace[:])
+	fmt.Fprintf(h, "%s\x00%d\x00%s", FullyQualifiedFunction(f), f.Lineno, buildID)
+	sum := h.Sum(nil)
+
+	var u [16]byte
+	copy(u[:], sum)
+	u[6] = (u[6] & 0x0f) | 0x50 // version 5 (name-based, SHA-1)
+	u[8] = (u[8] & 0x3f) | 0x80 // RFC 4122 variant
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:16])
+}
@@ -0,0 +1,56 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/errors/testutils"
+)
+
+// TestFunctionName checks the package/function split performed by
+// functionName, in particular for instantiated generic functions and
+// methods on generic receivers, where the bracketed type arguments
+// contain dots of their own.
+func TestFunctionName(t *testing.T) {
+	tt := testutils.T{T: t}
+
+	testData := []struct {
+		input string
+		pack  string
+		name  string
+	}{
+		{"example.com/pkg.Handle", "example.com/pkg", "Handle"},
+		{"example.com/pkg.(*Recv).Method", "example.com/pkg.(*Recv)", "Method"},
+		// Instantiated generic function: the split happens on the last
+		// dot before the first bracket, and the type arguments stay
+		// with the function name.
+		{"example.com/pkg.Foo[go.shape.int]", "example.com/pkg", "Foo[go.shape.int]"},
+		// Method on a generic receiver: the trailing method name stays
+		// attached after the brackets.
+		{"example.com/pkg.Recv[go.shape.string].Method", "example.com/pkg", "Recv[go.shape.string].Method"},
+		// Middle-dot separators are normalized.
+		{"runtime/debug.*T·ptrmethod", "runtime/debug", "*T.ptrmethod"},
+		// No package at all.
+		{"main", "", "main"},
+	}
+	for _, tc := range testData {
+		pack, name := functionName(tc.input)
+		tt.Run(tc.input, func(tt testutils.T) {
+			tt.CheckEqual(pack, tc.pack)
+			tt.CheckEqual(name, tc.name)
+		})
+	}
+}
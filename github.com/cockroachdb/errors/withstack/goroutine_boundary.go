@@ -0,0 +1,63 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+// goroutineBoundaryFunc is set via SetGoroutineBoundaryFunc().
+var goroutineBoundaryFunc string
+
+// SetGoroutineBoundaryFunc registers the fully-qualified name of a
+// function (e.g. "example.com/pool.(*Pool).worker") known to be the
+// entry point of pooled goroutines. When a frame matching the
+// boundary is found during extraction, that frame and all frames
+// below it (i.e. older, towards the goroutine start) are annotated
+// with "pooled_goroutine" = true in their Vars map.
+//
+// This helps distinguish request-path errors from background-pool
+// errors in grouping. Passing the empty string disables the
+// annotation, which is the default.
+func SetGoroutineBoundaryFunc(funcName string) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	goroutineBoundaryFunc = funcName
+}
+
+// maybeAnnotateGoroutineBoundary implements SetGoroutineBoundaryFunc
+// on the result of an extraction.
+func maybeAnnotateGoroutineBoundary(st *ReportableStackTrace) *ReportableStackTrace {
+	configMu.RLock()
+	boundaryFunc := goroutineBoundaryFunc
+	configMu.RUnlock()
+	if boundaryFunc == "" || st == nil {
+		return st
+	}
+	// The frames are stored oldest-first: look for the newest frame
+	// matching the boundary, then annotate it and everything older.
+	boundary := -1
+	for i := len(st.Frames) - 1; i >= 0; i-- {
+		f := st.Frames[i]
+		if f.Module+"."+f.Function == boundaryFunc || f.Function == boundaryFunc {
+			boundary = i
+			break
+		}
+	}
+	for i := 0; i <= boundary; i++ {
+		f := &st.Frames[i]
+		if f.Vars == nil {
+			f.Vars = map[string]interface{}{}
+		}
+		f.Vars["pooled_goroutine"] = true
+	}
+	return st
+}
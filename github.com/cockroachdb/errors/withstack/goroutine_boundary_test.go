@@ -0,0 +1,51 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/errors/testutils"
+)
+
+// TestGoroutineBoundaryAnnotation checks that registering a known
+// pool entry point annotates the matching frame and everything older
+// with the pooled_goroutine marker, leaving the newer frames alone.
+func TestGoroutineBoundaryAnnotation(t *testing.T) {
+	tt := testutils.T{T: t}
+
+	SetGoroutineBoundaryFunc("example.com/pool.(*Pool).worker")
+	defer SetGoroutineBoundaryFunc("")
+
+	// Frames are stored oldest first.
+	st := &ReportableStackTrace{Frames: []frame{
+		{Module: "runtime", Function: "goexit"},
+		{Module: "example.com/pool", Function: "(*Pool).worker"},
+		{Module: "example.com/app", Function: "Handle"},
+	}}
+	res := maybeAnnotateGoroutineBoundary(st)
+	tt.Assert(res != nil)
+	tt.AssertEqual(len(res.Frames), 3)
+
+	// The boundary frame and the older frame are annotated.
+	for _, i := range []int{0, 1} {
+		v, ok := res.Frames[i].Vars["pooled_goroutine"]
+		tt.Assert(ok)
+		tt.CheckEqual(v.(bool), true)
+	}
+	// The newer frame is not.
+	_, ok := res.Frames[2].Vars["pooled_goroutine"]
+	tt.Check(!ok)
+}
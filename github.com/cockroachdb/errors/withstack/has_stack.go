@@ -0,0 +1,31 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+import "github.com/cockroachdb/errors/errbase"
+
+// HasStack reports whether any level in err's chain of causes
+// carries a stack trace, using the same per-level detection as
+// GetReportableStackTrace. Helpers that capture a stack only when one
+// is not already present (e.g. errutil.WithMessageAndStack) use this
+// to avoid double capture.
+func HasStack(err error) bool {
+	for c := err; c != nil; c = errbase.UnwrapOnce(c) {
+		if getReportableStackTrace(c) != nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+import (
+	"crypto/sha256"
+	"fmt"
+)
+
+// GetHashedStackTrace extracts the stack trace embedded in the given
+// error (like GetReportableStackTrace) and returns one salted hash per
+// frame, oldest frame first, with no readable module, function or file
+// information whatsoever.
+//
+// Each hash is computed over the frame's module, function and line
+// number together with the caller-provided salt, so two structurally
+// identical stacks hashed with the same salt produce identical
+// sequences: recurring errors can be correlated across reports even by
+// a recipient who must not learn anything about the code. A recipient
+// without the salt cannot test guesses against the hashes either.
+//
+// The result is nil when no stack trace is available.
+func GetHashedStackTrace(err error, salt string) []string {
+	st := GetReportableStackTrace(err)
+	if st == nil {
+		return nil
+	}
+	hashes := make([]string, len(st.Frames))
+	for i, f := range st.Frames {
+		h := sha256.New()
+		fmt.Fprintf(h, "%s\x00%s\x00%d", salt, FullyQualifiedFunction(f), f.Lineno)
+		hashes[i] = fmt.Sprintf("%x", h.Sum(nil))
+	}
+	return hashes
+}
@@ -0,0 +1,49 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+// inAppPredicate is set via SetInAppPredicate().
+var inAppPredicate func(module, filename string) bool
+
+// SetInAppPredicate configures a predicate that decides, for each
+// frame produced by the extraction in GetReportableStackTrace(),
+// whether the frame is application code (the InApp flag in Sentry
+// reports). The predicate receives the frame's module (package path)
+// and trimmed file name.
+//
+// For example, a predicate matching the company's import prefix
+// highlights first-party frames in the Sentry UI for on-call
+// engineers.
+//
+// A nil predicate (the default) restores the previous behavior, where
+// every frame is marked in-app. For per-environment control over the
+// flag, see also StackPolicy.InAppPrefixes.
+func SetInAppPredicate(fn func(module, filename string) bool) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	inAppPredicate = fn
+}
+
+// computeInApp implements SetInAppPredicate at frame construction
+// time.
+func computeInApp(module, filename string) bool {
+	configMu.RLock()
+	fn := inAppPredicate
+	configMu.RUnlock()
+	if fn == nil {
+		return true
+	}
+	return fn(module, filename)
+}
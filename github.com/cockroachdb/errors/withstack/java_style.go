@@ -0,0 +1,51 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// FormatStackJavaStyle renders a reportable stack trace in the
+// Java-stacktrace format understood by JVM-oriented aggregators:
+//
+//	at module.function(file:line)
+//
+// The frames are rendered newest-first, like a JVM stack trace and in
+// the reverse of the storage order of ReportableStackTrace.
+//
+// The mapping to the Java "class.method" slot is: the Go module
+// (package import path) stands in for the package-qualified class
+// name, and the Go function (possibly a method with its receiver,
+// e.g. "(*T).m") stands in for the method. Only the base name of the
+// file is used, matching the Java convention of printing "Bar.java"
+// rather than a full path.
+func FormatStackJavaStyle(st *ReportableStackTrace) string {
+	if st == nil {
+		return ""
+	}
+	var buf strings.Builder
+	for i := len(st.Frames) - 1; i >= 0; i-- {
+		f := st.Frames[i]
+		fn := f.Function
+		if f.Module != "" && f.Module != "unknown" {
+			fn = f.Module + "." + fn
+		}
+		fmt.Fprintf(&buf, "\tat %s(%s:%d)\n", fn, filepath.Base(f.Filename), f.Lineno)
+	}
+	return buf.String()
+}
@@ -0,0 +1,55 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+import "encoding/json"
+
+// jsonFrame is the JSON schema of one frame in the output of
+// MarshalStackTraceJSON.
+type jsonFrame struct {
+	Module   string `json:"module"`
+	Function string `json:"function"`
+	File     string `json:"file"`
+	AbsPath  string `json:"abs_path"`
+	Line     int    `json:"line"`
+	InApp    bool   `json:"in_app"`
+}
+
+// MarshalStackTraceJSON renders the stack trace embedded in the given
+// error (like GetReportableStackTrace) as a JSON array of frame
+// objects, for telemetry consumers that are not Sentry. The frames
+// appear oldest first, matching the Sentry ordering.
+//
+// When the error carries no stack trace, the result is the JSON
+// literal "null", not an error: the caller can embed the result
+// unconditionally in a larger payload.
+func MarshalStackTraceJSON(err error) ([]byte, error) {
+	st := GetReportableStackTrace(err)
+	if st == nil {
+		return json.Marshal(nil)
+	}
+	frames := make([]jsonFrame, len(st.Frames))
+	for i, f := range st.Frames {
+		frames[i] = jsonFrame{
+			Module:   f.Module,
+			Function: f.Function,
+			File:     f.Filename,
+			AbsPath:  f.AbsPath,
+			Line:     f.Lineno,
+			InApp:    f.InApp,
+		}
+	}
+	return json.Marshal(frames)
+}
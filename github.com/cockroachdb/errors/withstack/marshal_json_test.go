@@ -0,0 +1,60 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/errors/testutils"
+)
+
+// TestMarshalStackTraceJSON checks the JSON schema of the marshaled
+// stack: the key names and their order are part of the format
+// consumed by external telemetry and must not drift.
+func TestMarshalStackTraceJSON(t *testing.T) {
+	tt := testutils.T{T: t}
+
+	buf, err := MarshalStackTraceJSON(WithStack(errors.New("hello")))
+	tt.Assert(err == nil)
+
+	// The payload decodes as a non-empty array of frame objects.
+	var frames []map[string]interface{}
+	tt.Assert(json.Unmarshal(buf, &frames) == nil)
+	tt.Assert(len(frames) > 0)
+	for _, key := range []string{"module", "function", "file", "abs_path", "line", "in_app"} {
+		if _, ok := frames[0][key]; !ok {
+			t.Errorf("key %q missing from marshaled frame", key)
+		}
+	}
+
+	// The keys appear in the documented order within each object.
+	firstObj := string(buf[:strings.IndexByte(string(buf), '}')+1])
+	prev := -1
+	for _, key := range []string{`"module"`, `"function"`, `"file"`, `"abs_path"`, `"line"`, `"in_app"`} {
+		idx := strings.Index(firstObj, key)
+		if idx < 0 || idx < prev {
+			t.Errorf("key %s missing or out of order in %s", key, firstObj)
+		}
+		prev = idx
+	}
+
+	// No stack: the result is the JSON literal null.
+	buf, err = MarshalStackTraceJSON(errors.New("hello"))
+	tt.Assert(err == nil)
+	tt.CheckEqual(string(buf), "null")
+}
@@ -0,0 +1,70 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GetReportableStackTraceForModule extracts the stack trace embedded
+// in the given error (like GetReportableStackTrace) but only retains
+// the frames whose Module matches the given prefix.
+//
+// The relative order of the retained frames is preserved. Each
+// contiguous run of out-of-module frames is replaced by a single
+// synthetic boundary frame indicating how many frames were elided, so
+// that gaps in the call path remain visible.
+//
+// The result is nil if no stack trace is available.
+func GetReportableStackTraceForModule(err error, modulePrefix string) *ReportableStackTrace {
+	return FilterStackForModule(GetReportableStackTrace(err), modulePrefix)
+}
+
+// FilterStackForModule is the stack-level counterpart of
+// GetReportableStackTraceForModule: it operates directly on an
+// already-extracted (or stored and re-loaded, see
+// UnmarshalReportableStackTrace) reportable stack trace.
+func FilterStackForModule(st *ReportableStackTrace, modulePrefix string) *ReportableStackTrace {
+	if st == nil {
+		return nil
+	}
+	var frames []frame
+	elided := 0
+	for _, f := range st.Frames {
+		if strings.HasPrefix(f.Module, modulePrefix) {
+			if elided > 0 {
+				frames = append(frames, elidedFrame(elided))
+				elided = 0
+			}
+			frames = append(frames, f)
+		} else {
+			elided++
+		}
+	}
+	if elided > 0 {
+		frames = append(frames, elidedFrame(elided))
+	}
+	return &ReportableStackTrace{Frames: frames}
+}
+
+// elidedFrame produces the synthetic boundary frame that stands in
+// for n contiguous out-of-module frames.
+func elidedFrame(n int) frame {
+	return frame{
+		Module:   "<external>",
+		Function: fmt.Sprintf("[%d frames elided]", n),
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+import (
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+// useModuleRootRelativePaths is set via UseModuleRootRelativePaths().
+var useModuleRootRelativePaths = false
+
+// UseModuleRootRelativePaths enables or disables rewriting frame
+// filenames to be relative to the main module's root, as reported by
+// debug.ReadBuildInfo(). For example a file in module "example.com/svc"
+// is reported as "server/handler.go" rather than the "/src/"-trimmed
+// "example.com/svc/server/handler.go".
+//
+// This yields clean, build-location-independent paths for the
+// application's own code. Frames outside the main module, and builds
+// where build information is unavailable (e.g. GOPATH builds), keep
+// the regular trimPath behavior.
+//
+// The feature is disabled by default.
+func UseModuleRootRelativePaths(enable bool) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	useModuleRootRelativePaths = enable
+}
+
+func getUseModuleRootRelativePaths() bool {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return useModuleRootRelativePaths
+}
+
+var mainModulePathOnce sync.Once
+var mainModulePath string
+
+// getMainModulePath returns the import path of the main module, or
+// the empty string when build information is unavailable.
+func getMainModulePath() string {
+	mainModulePathOnce.Do(func() {
+		if bi, ok := debug.ReadBuildInfo(); ok {
+			mainModulePath = bi.Main.Path
+		}
+	})
+	return mainModulePath
+}
+
+// maybeTrimModuleRoot implements UseModuleRootRelativePaths on the
+// result of an extraction.
+func maybeTrimModuleRoot(st *ReportableStackTrace) *ReportableStackTrace {
+	if !getUseModuleRootRelativePaths() || st == nil {
+		return st
+	}
+	root := getMainModulePath()
+	if root == "" {
+		// No build info: degrade to the regular trimPath behavior.
+		return st
+	}
+	prefix := root + "/"
+	for i := range st.Frames {
+		f := &st.Frames[i]
+		if trimmed := strings.TrimPrefix(f.Filename, prefix); len(trimmed) < len(f.Filename) {
+			f.Filename = trimmed
+		} else if j := strings.Index(f.AbsPath, prefix); j >= 0 {
+			// The module path can appear mid-way in the absolute path,
+			// e.g. in the module cache.
+			f.Filename = f.AbsPath[j+len(prefix):]
+		}
+	}
+	return st
+}
@@ -0,0 +1,52 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+// noiseClassifier is set via SetNoiseClassifier().
+var noiseClassifier func(st *ReportableStackTrace) bool
+
+// SetNoiseClassifier installs a classifier consulted by
+// ShouldReport(). The classifier receives the error's reportable
+// stack trace and returns true when the error is known noise (e.g. a
+// flaky third-party timeout identified by its origin frames) that
+// should not be reported.
+//
+// This centralizes a noise-suppression policy keyed on the stack
+// shape in one place; reporting code only needs to check
+// ShouldReport() before sending. Passing nil removes the classifier,
+// which is the default.
+func SetNoiseClassifier(fn func(st *ReportableStackTrace) bool) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	noiseClassifier = fn
+}
+
+// ShouldReport extracts the stack trace embedded in err (like
+// GetReportableStackTrace) and consults the classifier registered via
+// SetNoiseClassifier() to decide whether the error is worth
+// reporting.
+//
+// When no classifier is registered, every error should be reported.
+// The classifier is also consulted for errors without a stack trace,
+// with a nil argument.
+func ShouldReport(err error) bool {
+	configMu.RLock()
+	fn := noiseClassifier
+	configMu.RUnlock()
+	if fn == nil {
+		return true
+	}
+	return !fn(GetReportableStackTrace(err))
+}
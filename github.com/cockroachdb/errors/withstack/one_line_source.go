@@ -75,10 +75,21 @@ func getOneLineSourceFromPkgStack(
 }
 
 func getOneLineSourceFromPrintedStack(st string) (file string, line int, fn string, ok bool) {
+	st = strings.TrimSpace(st)
+	// The payload may carry a Go version header (see
+	// printedStackGoVersionPrefix); it is not a stack entry, skip it.
+	if strings.HasPrefix(st, printedStackGoVersionPrefix) {
+		i := strings.IndexByte(st, '\n')
+		if i < 0 {
+			// Header-only payload: no frames.
+			return "", 0, "", false
+		}
+		st = strings.TrimSpace(st[i+1:])
+	}
 	// We only need 3 lines: the function/file/line info will be on the first two lines.
 	// See parsePrintedStack() for details.
-	lines := strings.SplitN(strings.TrimSpace(st), "\n", 3)
-	if len(lines) > 0 {
+	lines := strings.SplitN(st, "\n", 3)
+	if len(lines) > 0 && lines[0] != "" {
 		_, file, line, fnName := parsePrintedStackEntry(lines, 0)
 		if fnName != "unknown" {
 			_, fn = functionName(fnName)
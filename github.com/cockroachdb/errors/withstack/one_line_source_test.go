@@ -0,0 +1,63 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cockroachdb/errors/errbase"
+	"github.com/cockroachdb/errors/testutils"
+)
+
+// TestOneLineSourceSkipsGoVersionHeader checks that the printed-stack
+// parser used by GetOneLineSource() ignores the Go version header that
+// heads flattened stack payloads.
+func TestOneLineSourceSkipsGoVersionHeader(t *testing.T) {
+	tt := testutils.T{T: t}
+
+	payload := printedStackGoVersionPrefix + "go1.21\n" +
+		"foo.com/pkg.Fn\n\t/src/foo/file.go:42"
+	file, line, fn, ok := getOneLineSourceFromPrintedStack(payload)
+	tt.Assert(ok)
+	tt.CheckEqual(file, "file.go")
+	tt.CheckEqual(line, 42)
+	tt.CheckEqual(fn, "Fn")
+
+	// A header-only payload carries no frames.
+	_, _, _, ok = getOneLineSourceFromPrintedStack(printedStackGoVersionPrefix + "go1.21")
+	tt.Check(!ok)
+}
+
+// TestOneLineSourceAfterDecode checks that GetOneLineSource() reports
+// the same source position before and after an encode/decode cycle,
+// when the stack trace has been flattened to a safe detail string.
+func TestOneLineSourceAfterDecode(t *testing.T) {
+	tt := testutils.T{T: t}
+	ctx := context.Background()
+
+	origErr := WithStack(errors.New("hello"))
+	file, line, fn, ok := GetOneLineSource(origErr)
+	tt.Assert(ok)
+
+	enc := errbase.EncodeError(ctx, origErr)
+	dec := errbase.DecodeError(ctx, enc)
+	dfile, dline, dfn, dok := GetOneLineSource(dec)
+	tt.Assert(dok)
+	tt.CheckEqual(dfile, file)
+	tt.CheckEqual(dline, line)
+	tt.CheckEqual(dfn, fn)
+}
@@ -0,0 +1,43 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+import "strings"
+
+// OriginIsThirdParty reports whether the origin (oldest) frame of the
+// stack trace embedded in err belongs to a module outside the given
+// list of "own" module prefixes.
+//
+// This provides a quick ownership signal for triage routing: errors
+// originating in a third-party dependency can be routed to a
+// different queue than errors originating in the application's own
+// code.
+//
+// The function returns false when no stack trace is available, since
+// in that case no origin can be determined.
+func OriginIsThirdParty(err error, ownModulePrefixes []string) bool {
+	st := GetReportableStackTrace(err)
+	if st == nil || len(st.Frames) == 0 {
+		return false
+	}
+	// The frames are stored oldest-first; the origin is the first one.
+	origin := st.Frames[0]
+	for _, prefix := range ownModulePrefixes {
+		if strings.HasPrefix(origin.Module, prefix) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,84 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+import "strings"
+
+// ownershipMap is set via SetOwnershipMap().
+var ownershipMap map[string]string
+
+// SetOwnershipMap configures a CODEOWNERS-style mapping from source
+// path prefixes to team names, consulted by GetOwningTeam(). The
+// prefixes are matched against the (trimmed) file paths of stack
+// frames, longest prefix winning.
+//
+// A nil map (the default) disables ownership resolution.
+func SetOwnershipMap(m map[string]string) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	ownershipMap = m
+}
+
+func getOwnershipMap() map[string]string {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return ownershipMap
+}
+
+// GetOwningTeam resolves the team owning the code where the given
+// error originated, per the mapping configured with SetOwnershipMap().
+//
+// The resolution uses the error's stack trace: the origin (oldest)
+// frame, or the first in-app frame above it when the origin frame's
+// file is not covered by the mapping. The boolean return is false when
+// no mapping is configured, the error carries no stack trace, or no
+// frame's file matches any configured prefix.
+func GetOwningTeam(err error) (string, bool) {
+	owners := getOwnershipMap()
+	if len(owners) == 0 {
+		return "", false
+	}
+	st := GetReportableStackTrace(err)
+	if st == nil {
+		return "", false
+	}
+	// The frames are oldest first: the origin frame is considered
+	// first, then the in-app frames above it.
+	for i, f := range st.Frames {
+		if i > 0 && !f.InApp {
+			continue
+		}
+		if team, ok := lookupOwner(owners, f.Filename); ok {
+			return team, true
+		}
+	}
+	return "", false
+}
+
+// lookupOwner finds the team owning the given file path, preferring
+// the longest matching prefix.
+func lookupOwner(owners map[string]string, file string) (string, bool) {
+	var bestPrefix, bestTeam string
+	found := false
+	for prefix, team := range owners {
+		if !strings.HasPrefix(file, prefix) {
+			continue
+		}
+		if !found || len(prefix) > len(bestPrefix) {
+			bestPrefix, bestTeam, found = prefix, team, true
+		}
+	}
+	return bestTeam, found
+}
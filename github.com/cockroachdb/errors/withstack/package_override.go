@@ -0,0 +1,44 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+// packageOverride is set via SetPackageOverride().
+var packageOverride func(module string) string
+
+// SetPackageOverride installs a hook that maps a frame's module to
+// the value stored in the frame's Package field. Sentry uses Package
+// for grouping in some SDKs; mapping modules to coarser package
+// labels can improve grouping without altering the Module and
+// Function fields.
+//
+// By default (and when passing nil), the Package field is populated
+// with the Module value as-is.
+func SetPackageOverride(fn func(module string) string) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	packageOverride = fn
+}
+
+// packageLabel computes the Package field value for a frame with the
+// given module.
+func packageLabel(module string) string {
+	configMu.RLock()
+	fn := packageOverride
+	configMu.RUnlock()
+	if fn == nil {
+		return module
+	}
+	return fn(module)
+}
@@ -0,0 +1,75 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// goroutineHeaderRe matches the heading of a goroutine in a runtime
+// stack dump, e.g. "goroutine 13 [running]:".
+var goroutineHeaderRe = regexp.MustCompile(`(?m)^goroutine \d+ \[[^\]]*\]:$`)
+
+// ExtractStackFromPanicMessage salvages a stack trace from a panic
+// message that embeds a goroutine stack dump, e.g. because a library
+// recovered a panic and re-reported it as a string. The first
+// goroutine block found ("goroutine N [running]:" followed by frame
+// pairs) is parsed; the remainder of the message is ignored.
+//
+// The result is nil when the message contains no recognizable
+// goroutine block. Like GetReportableStackTrace, the oldest call
+// frame comes first in the result.
+func ExtractStackFromPanicMessage(msg string) *ReportableStackTrace {
+	loc := goroutineHeaderRe.FindStringIndex(msg)
+	if loc == nil {
+		return nil
+	}
+	body := strings.TrimPrefix(msg[loc[1]:], "\n")
+
+	// Convert the frames of the first goroutine to the
+	// github.com/pkg/errors printed format understood by
+	// parsePrintedStack: drop the argument lists after the function
+	// names and the "+0x..." offsets after the source locations.
+	var buf strings.Builder
+	for _, line := range strings.Split(body, "\n") {
+		if strings.TrimSpace(line) == "" ||
+			strings.HasPrefix(line, "goroutine ") ||
+			strings.HasPrefix(line, "created by ") {
+			// End of the first goroutine's own frames.
+			break
+		}
+		if strings.HasPrefix(line, "\t") {
+			srcLoc := strings.TrimSpace(line)
+			if idx := strings.LastIndex(srcLoc, " +0x"); idx != -1 {
+				srcLoc = srcLoc[:idx]
+			}
+			fmt.Fprintf(&buf, "\t%s\n", srcLoc)
+		} else {
+			fnName := line
+			// The argument list is the last parenthesized group; a
+			// method receiver like (*T) appears earlier in the name.
+			if idx := strings.LastIndex(fnName, "("); idx != -1 {
+				fnName = fnName[:idx]
+			}
+			fmt.Fprintf(&buf, "%s\n", fnName)
+		}
+	}
+	if buf.Len() == 0 {
+		return nil
+	}
+	return parsePrintedStack(buf.String())
+}
@@ -0,0 +1,54 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/errors/testutils"
+)
+
+// TestExtractStackFromPanicMessage checks that a goroutine dump
+// embedded in a panic message is parsed into a reportable stack:
+// argument lists and +0x offsets are stripped, and only the first
+// goroutine's own frames are used.
+func TestExtractStackFromPanicMessage(t *testing.T) {
+	tt := testutils.T{T: t}
+
+	msg := `panic: runtime error: index out of range [3]
+
+goroutine 13 [running]:
+example.com/app.(*Server).handle(0xc000123456, 0x3)
+	/src/example.com/app/server.go:42 +0x1b
+example.com/app.main()
+	/src/example.com/app/main.go:10 +0x45
+created by net/http.(*Server).Serve
+	/usr/local/go/src/net/http/server.go:3086 +0x5cb
+`
+	st := ExtractStackFromPanicMessage(msg)
+	tt.Assert(st != nil)
+	tt.AssertEqual(len(st.Frames), 2)
+
+	// Oldest frame first; the "created by" section is not included.
+	tt.CheckEqual(st.Frames[0].Function, "main")
+	tt.CheckEqual(st.Frames[0].AbsPath, "/src/example.com/app/main.go")
+	tt.CheckEqual(st.Frames[0].Lineno, 10)
+	tt.CheckEqual(st.Frames[1].Function, "handle")
+	tt.CheckEqual(st.Frames[1].AbsPath, "/src/example.com/app/server.go")
+	tt.CheckEqual(st.Frames[1].Lineno, 42)
+
+	// A message without a goroutine block yields nothing.
+	tt.Check(ExtractStackFromPanicMessage("panic: boom") == nil)
+}
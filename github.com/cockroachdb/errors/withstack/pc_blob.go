@@ -0,0 +1,132 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"runtime"
+	"strings"
+
+	"github.com/cockroachdb/errors/errbase"
+)
+
+// pcBlobMarker prefixes a safe detail string that carries a
+// base64-encoded list of raw program counters instead of a printed
+// stack trace. The literal lives in errbase so that it can also be
+// recognized there (e.g. by EncodeErrorLimited) without an import
+// cycle.
+const pcBlobMarker = errbase.StackDetailPCBlobPrefix
+
+// EncodePCBlob encodes a list of raw program counters into a compact
+// string suitable for inclusion in an error's safe details. The
+// payload contains no symbolication whatsoever; it is only the PCs,
+// base64-encoded behind a recognizable marker.
+//
+// The complementary decoding is performed by DecodePCBlob and, for
+// errors, by GetReportableStackTrace which symbolicates the PCs via
+// SymbolicatePCs.
+//
+// Note: program counters are only meaningful within the binary that
+// captured them. A blob produced by one build of a program cannot be
+// symbolicated by another build; the encoder and the decoder must run
+// in the same binary.
+func EncodePCBlob(pcs []uintptr) string {
+	buf := make([]byte, 8*len(pcs))
+	for i, pc := range pcs {
+		binary.LittleEndian.PutUint64(buf[8*i:], uint64(pc))
+	}
+	return pcBlobMarker + base64.StdEncoding.EncodeToString(buf)
+}
+
+// DecodePCBlob decodes a string produced by EncodePCBlob back into
+// the list of raw program counters. The boolean return is false if
+// the string does not carry the PC-blob marker or is malformed.
+func DecodePCBlob(detail string) ([]uintptr, bool) {
+	if !strings.HasPrefix(detail, pcBlobMarker) {
+		return nil, false
+	}
+	buf, err := base64.StdEncoding.DecodeString(detail[len(pcBlobMarker):])
+	if err != nil || len(buf)%8 != 0 {
+		return nil, false
+	}
+	pcs := make([]uintptr, len(buf)/8)
+	for i := range pcs {
+		pcs[i] = uintptr(binary.LittleEndian.Uint64(buf[8*i:]))
+	}
+	return pcs, true
+}
+
+// SymbolicatePCs converts a list of raw program counters into a
+// ReportableStackTrace, resolving file/line/function information via
+// the Go runtime's symbol tables.
+//
+// The expansion uses runtime.CallersFrames' More()/Next() iteration,
+// so a PC that stands for multiple logical calls (due to inlining)
+// yields one reportable frame per logical frame: inlined helper
+// functions appear in the result as separate frames.
+//
+// The PCs must have been captured by the same binary that calls
+// SymbolicatePCs: symbol tables are not stable across builds and the
+// result of symbolicating foreign PCs is garbage.
+//
+// Note: Sentry wants the oldest call frame first, so
+// the entries are reversed in the result.
+func SymbolicatePCs(pcs []uintptr) *ReportableStackTrace {
+	// If there are no PCs, the entire stacktrace is nil.
+	if len(pcs) == 0 {
+		return nil
+	}
+
+	var frames []frame
+	ci := runtime.CallersFrames(pcs)
+	for {
+		f, more := ci.Next()
+		fr := frame{
+			AbsPath:  f.File,
+			Filename: trimPath(f.File),
+			Lineno:   f.Line,
+			InApp:    true,
+			Module:   "unknown",
+			Function: "unknown",
+		}
+		if f.Function != "" {
+			// Keep the original runtime symbol in the Symbol field;
+			// Function below carries the display form.
+			fr.Symbol = f.Function
+			// Extract the function/module details.
+			fr.Module, fr.Function = functionName(f.Function)
+			fr.Function = truncateName(demangle(fr.Function))
+			fr.Module = truncateName(fr.Module)
+		}
+		fr.InApp = computeInApp(fr.Module, fr.Filename)
+		fr.Package = packageLabel(fr.Module)
+		frames = append(frames, enrich(fr))
+		if !more {
+			break
+		}
+	}
+
+	if frames == nil {
+		return nil
+	}
+
+	// Sentry wants the frames with the oldest first, so reverse them.
+	for i, j := 0, len(frames)-1; i < j; i, j = i+1, j-1 {
+		frames[i], frames[j] = frames[j], frames[i]
+	}
+
+	return &ReportableStackTrace{Frames: frames}
+}
@@ -0,0 +1,99 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/errors/testutils"
+)
+
+// pcBlobError is a test error carrying a PC blob in its safe details.
+type pcBlobError struct {
+	detail string
+}
+
+func (e *pcBlobError) Error() string         { return "pcs" }
+func (e *pcBlobError) SafeDetails() []string { return []string{e.detail} }
+
+// capturePCsForTest captures the program counters of its own call
+// site. Named so that the symbolication result can be checked for it.
+//go:noinline
+func capturePCsForTest() []uintptr {
+	var pcs [16]uintptr
+	n := runtime.Callers(1, pcs[:])
+	return pcs[:n]
+}
+
+// inlinedCapture is small enough for the compiler to inline into its
+// caller, so the PC at its call site stands for two logical frames.
+func inlinedCapture() []uintptr {
+	return capturePCsForTest()
+}
+
+// outerCaller keeps a frame of its own around the inlined call.
+//go:noinline
+func outerCaller() []uintptr {
+	return inlinedCapture()
+}
+
+// TestSymbolicatePCsExpandsInlinedFrames checks that a PC standing
+// for multiple logical calls due to inlining yields one reportable
+// frame per logical call: the inlined helper and its caller both
+// appear as separate frames.
+func TestSymbolicatePCsExpandsInlinedFrames(t *testing.T) {
+	tt := testutils.T{T: t}
+
+	st := SymbolicatePCs(outerCaller())
+	tt.Assert(st != nil)
+	foundInlined, foundCaller := false, false
+	for _, f := range st.Frames {
+		if strings.Contains(f.Function, "inlinedCapture") {
+			foundInlined = true
+		}
+		if strings.Contains(f.Function, "outerCaller") {
+			foundCaller = true
+		}
+	}
+	tt.Check(foundInlined)
+	tt.Check(foundCaller)
+}
+
+// TestPCBlobRoundTrip checks that raw program counters survive an
+// encode/decode cycle through the blob format, and that
+// GetReportableStackTrace symbolicates a blob-carrying error back to
+// the capturing function.
+func TestPCBlobRoundTrip(t *testing.T) {
+	tt := testutils.T{T: t}
+
+	pcs := capturePCsForTest()
+	blob := EncodePCBlob(pcs)
+
+	decoded, ok := DecodePCBlob(blob)
+	tt.Assert(ok)
+	tt.CheckDeepEqual(decoded, pcs)
+
+	st := GetReportableStackTrace(&pcBlobError{detail: blob})
+	tt.Assert(st != nil)
+	found := false
+	for _, f := range st.Frames {
+		if strings.Contains(f.Function, "capturePCsForTest") {
+			found = true
+		}
+	}
+	tt.Check(found)
+}
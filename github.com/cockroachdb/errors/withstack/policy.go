@@ -0,0 +1,105 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+import "strings"
+
+// StackPolicy bundles the frame processing rules for one reporting
+// environment (e.g. "dev", "staging", "prod"), so that the reporting
+// behavior can be selected per call instead of reconfiguring global
+// options. See SetRedactionPolicy() and
+// GetReportableStackTraceWithPolicy().
+type StackPolicy struct {
+	// InAppPrefixes lists module prefixes considered application
+	// code; matching frames get their InApp flag set, all others get
+	// it cleared. An empty list leaves the InApp flags unchanged.
+	InAppPrefixes []string
+	// DenyModulePrefixes lists module prefixes whose frames are
+	// dropped from the result entirely.
+	DenyModulePrefixes []string
+	// TrimPathPrefixes lists additional path prefixes stripped from
+	// the frame filenames.
+	TrimPathPrefixes []string
+	// RedactLineNumbers, if set, zeroes the line number of every
+	// frame.
+	RedactLineNumbers bool
+	// RedactFileNames, if set, replaces the file path of every frame
+	// by a redaction marker.
+	RedactFileNames bool
+}
+
+// stackPolicies is the registry of named policies. See
+// SetRedactionPolicy().
+var stackPolicies = map[string]StackPolicy{}
+
+// SetRedactionPolicy registers (or replaces) a named stack processing
+// policy for use with GetReportableStackTraceWithPolicy().
+func SetRedactionPolicy(name string, policy StackPolicy) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	stackPolicies[name] = policy
+}
+
+// GetReportableStackTraceWithPolicy extracts the stack trace embedded
+// in the given error (like GetReportableStackTrace) and applies the
+// named policy to the result. When no policy is registered under that
+// name, the stack is returned unprocessed.
+func GetReportableStackTraceWithPolicy(err error, policyName string) *ReportableStackTrace {
+	st := GetReportableStackTrace(err)
+	configMu.RLock()
+	policy, ok := stackPolicies[policyName]
+	configMu.RUnlock()
+	if !ok || st == nil {
+		return st
+	}
+	return applyStackPolicy(st, policy)
+}
+
+// applyStackPolicy applies one policy to an extracted stack.
+func applyStackPolicy(st *ReportableStackTrace, policy StackPolicy) *ReportableStackTrace {
+	var frames []frame
+	for _, f := range st.Frames {
+		if matchesAnyPrefix(f.Module, policy.DenyModulePrefixes) {
+			continue
+		}
+		if len(policy.InAppPrefixes) > 0 {
+			f.InApp = matchesAnyPrefix(f.Module, policy.InAppPrefixes)
+		}
+		for _, prefix := range policy.TrimPathPrefixes {
+			if trimmed := strings.TrimPrefix(f.Filename, prefix); len(trimmed) < len(f.Filename) {
+				f.Filename = trimmed
+				break
+			}
+		}
+		if policy.RedactLineNumbers {
+			f.Lineno = 0
+		}
+		if policy.RedactFileNames {
+			f.Filename = "<redacted>"
+			f.AbsPath = "<redacted>"
+		}
+		frames = append(frames, f)
+	}
+	return &ReportableStackTrace{Frames: frames}
+}
+
+func matchesAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
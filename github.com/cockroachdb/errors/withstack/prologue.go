@@ -0,0 +1,60 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+// commonPrologue, when non-empty, is the shared sequence of oldest
+// frames that GetReportableStackTrace() collapses into a single
+// marker frame. See SetCommonPrologue().
+var commonPrologue []Frame
+
+// SetCommonPrologue registers a sequence of frames (oldest first, as
+// in ReportableStackTrace) that is common to every stack captured by
+// the application, e.g. a framework's router and middleware chain.
+//
+// When the oldest frames of an extracted stack exactly match the
+// registered prologue (as determined by the frame comparator, see
+// SetFrameComparator), they are collapsed into one "[framework]"
+// marker frame, decluttering the reports without hiding that the
+// prologue was present.
+//
+// Passing nil or an empty slice disables the collapsing.
+func SetCommonPrologue(frames []Frame) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	commonPrologue = frames
+}
+
+// maybeCollapsePrologue implements SetCommonPrologue on the result of
+// an extraction.
+func maybeCollapsePrologue(st *ReportableStackTrace) *ReportableStackTrace {
+	configMu.RLock()
+	prologue := commonPrologue
+	eq := frameEqual
+	configMu.RUnlock()
+	if st == nil || len(prologue) == 0 || len(st.Frames) < len(prologue) {
+		return st
+	}
+	// The frames are stored oldest-first, so the prologue, if present,
+	// sits at the beginning of the slice.
+	for i := range prologue {
+		if !eq(st.Frames[i], prologue[i]) {
+			return st
+		}
+	}
+	frames := make([]frame, 0, len(st.Frames)-len(prologue)+1)
+	frames = append(frames, frame{Module: "<framework>", Function: "[framework]"})
+	frames = append(frames, st.Frames[len(prologue):]...)
+	return &ReportableStackTrace{Frames: frames}
+}
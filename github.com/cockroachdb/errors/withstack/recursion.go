@@ -0,0 +1,79 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+import "fmt"
+
+// recursionThreshold is the minimum number of repetitions of a single
+// function for a stack to be flagged as recursive by
+// DetectRecursion.
+const recursionThreshold = 8
+
+// DetectRecursion inspects a reportable stack trace for the signature
+// of runaway recursion: a single function dominating the stack
+// through repetition. It returns whether the stack is recursive, the
+// fully-qualified name of the repeated function, and how many times
+// it appears.
+//
+// A stack is considered recursive when one function accounts for at
+// least recursionThreshold frames and for at least half of all
+// frames. Combined with CollapseRecursiveFrames, this allows both
+// detecting and compacting pathological recursion stacks before
+// reporting.
+func DetectRecursion(st *ReportableStackTrace) (isRecursive bool, repeatedFunc string, depth int) {
+	if st == nil {
+		return false, "", 0
+	}
+	counts := map[string]int{}
+	for _, f := range st.Frames {
+		counts[f.Module+"."+f.Function]++
+	}
+	for fn, n := range counts {
+		if n > depth {
+			repeatedFunc, depth = fn, n
+		}
+	}
+	if depth >= recursionThreshold && 2*depth >= len(st.Frames) {
+		return true, repeatedFunc, depth
+	}
+	return false, "", 0
+}
+
+// CollapseRecursiveFrames returns a copy of the given stack trace in
+// which every run of consecutive equal frames (as determined by the
+// frame comparator, see SetFrameComparator) is folded into a single
+// frame annotated with the repetition count. Nil input yields nil.
+func CollapseRecursiveFrames(st *ReportableStackTrace) *ReportableStackTrace {
+	if st == nil {
+		return nil
+	}
+	eq := getFrameEqual()
+	var frames []frame
+	for i := 0; i < len(st.Frames); i++ {
+		f := st.Frames[i]
+		j := i + 1
+		for ; j < len(st.Frames); j++ {
+			if !eq(st.Frames[j], f) {
+				break
+			}
+		}
+		if n := j - i; n > 1 {
+			f.Function = fmt.Sprintf("%s [repeated %d times]", f.Function, n)
+		}
+		frames = append(frames, f)
+		i = j - 1
+	}
+	return &ReportableStackTrace{Frames: frames}
+}
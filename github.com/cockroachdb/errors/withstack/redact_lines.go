@@ -0,0 +1,54 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+// redactLineNumbers is set via RedactLineNumbers().
+var redactLineNumbers = false
+
+// RedactLineNumbers enables or disables the zeroing of line numbers
+// in the stack traces produced by GetReportableStackTrace(), while
+// keeping the file and function names intact.
+//
+// This is a coarser privacy control than full module redaction, for
+// compliance contexts where even line numbers are considered
+// revealing (they can leak code structure to a third-party SaaS):
+// the retained fields are still enough for grouping, without
+// line-level detail.
+//
+// The feature is disabled by default. For per-environment control,
+// see also StackPolicy.RedactLineNumbers.
+func RedactLineNumbers(enable bool) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	redactLineNumbers = enable
+}
+
+func getRedactLineNumbers() bool {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return redactLineNumbers
+}
+
+// maybeRedactLineNumbers implements RedactLineNumbers on the result
+// of an extraction.
+func maybeRedactLineNumbers(st *ReportableStackTrace) *ReportableStackTrace {
+	if !getRedactLineNumbers() || st == nil {
+		return st
+	}
+	for i := range st.Frames {
+		st.Frames[i].Lineno = 0
+	}
+	return st
+}
@@ -0,0 +1,56 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+// frameReleaseAnnotator is consulted per frame during extraction. See
+// SetFrameReleaseAnnotator().
+var frameReleaseAnnotator func(module, function string, line int) string
+
+// SetFrameReleaseAnnotator installs a hook invoked for every frame
+// during extraction; the returned release tag (e.g. the release in
+// which the frame's code first appeared, resolved from an external
+// database) is attached to the frame metadata under the
+// "first_seen_release" key in the Vars map. An empty return leaves
+// the frame unchanged.
+//
+// This helps grouping surface errors located in newly-shipped code.
+// The default (and the effect of passing nil) is to leave all frames
+// unchanged.
+func SetFrameReleaseAnnotator(fn func(module, function string, line int) string) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	frameReleaseAnnotator = fn
+}
+
+// maybeAnnotateReleases implements SetFrameReleaseAnnotator on the
+// result of an extraction.
+func maybeAnnotateReleases(st *ReportableStackTrace) *ReportableStackTrace {
+	configMu.RLock()
+	annotate := frameReleaseAnnotator
+	configMu.RUnlock()
+	if annotate == nil || st == nil {
+		return st
+	}
+	for i := range st.Frames {
+		f := &st.Frames[i]
+		if tag := annotate(f.Module, f.Function, f.Lineno); tag != "" {
+			if f.Vars == nil {
+				f.Vars = map[string]interface{}{}
+			}
+			f.Vars["first_seen_release"] = tag
+		}
+	}
+	return st
+}
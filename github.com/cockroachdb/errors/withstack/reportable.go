@@ -39,27 +39,63 @@ type ReportableStackTrace = sentry.Stacktrace
 //   locally or after transfer through the network),
 // - errors generated with WithStack() in this package,
 // - any other error that implements a StackTrace() method
-//   returning a StackTrace from github.com/pkg/errors.
+//   returning a StackTrace from github.com/pkg/errors,
+// - any other error that implements a StackTrace() method
+//   returning a []runtime.Frame.
 //
 // Note: Sentry wants the oldest call frame first, so
 // the entries are reversed in the result.
 func GetReportableStackTrace(err error) *ReportableStackTrace {
+	st := maybeCollapsePrologue(getReportableStackTrace(err))
+	st = maybeCollapseErrorsFrames(st)
+	st = maybeCollapseClosures(st)
+	st = maybeAnnotateReleases(st)
+	st = maybeTrimModuleRoot(st)
+	st = maybeAnnotateGoroutineBoundary(st)
+	st = maybeAttachSpanContext(st, err)
+	st = maybeRedactLineNumbers(st)
+	return maybeAttachTraceID(st, err)
+}
+
+// getReportableStackTrace implements the extraction proper, without
+// the optional metadata attachments.
+func getReportableStackTrace(err error) *ReportableStackTrace {
 	// If we have a stack trace in the style of github.com/pkg/errors
 	// (either from there or our own withStack), use it.
 	if st, ok := err.(errbase.StackTraceProvider); ok {
 		return convertPkgStack(st.StackTrace())
 	}
 
+	// Some third-party libraries attach a stack trace as
+	// already-resolved runtime.Frame values.
+	if st, ok := err.(runtimeFramesProvider); ok {
+		return convertRuntimeFrames(st.StackTrace())
+	}
+
 	// If we have flattened a github.com/pkg/errors-style stack
 	// trace to a string, it will happen in the error's safe details
 	// and we need to parse it.
 	if sd, ok := err.(errbase.SafeDetailer); ok {
 		details := sd.SafeDetails()
 		if len(details) > 0 {
+			// If the details carry a compact PC blob (see EncodePCBlob),
+			// decode and symbolicate it. This is only valid within the
+			// same binary that produced the blob.
+			if pcs, ok := DecodePCBlob(details[0]); ok {
+				return SymbolicatePCs(pcs)
+			}
 			switch errbase.GetTypeKey(err) {
 			case pkgFundamental, pkgWithStackName, ourWithStackName:
 				return parsePrintedStack(details[0])
 			}
+			// Other error types can deliberately carry a stack payload in
+			// their own safe details, e.g. barriers that surface the stack
+			// of an error they mask. This is opt-in: the payload must be
+			// marked with RetainStackDetail(), so that a detail string
+			// that merely resembles a stack is not promoted by accident.
+			if d, ok := RetainedStackDetail(details[0]); ok && IsStackDetail(d) {
+				return parsePrintedStack(d)
+			}
 		}
 	}
 
@@ -77,19 +113,64 @@ func convertPkgStack(st errbase.StackTrace) *ReportableStackTrace {
 		return nil
 	}
 
+	// The frames of a github.com/pkg/errors StackTrace are raw
+	// program counters (callers-style, i.e. return addresses).
+	// Prefer resolving them directly via runtime.CallersFrames: this
+	// yields accurate file/line/function data independent of the Go
+	// version used to print the stack, and expands inlined calls into
+	// separate frames, which the printed form collapses.
+	pcs := make([]uintptr, len(st))
+	for i, f := range st {
+		pcs[i] = uintptr(f)
+	}
+	if r := SymbolicatePCs(pcs); r != nil && anyFrameResolved(r) {
+		return r
+	}
+
+	// The PCs could not be resolved by this binary's symbol tables;
+	// fall back to parsing the printed representation.
+	//
 	// Note: the stack trace logic changed between go 1.11 and 1.12.
 	// Trying to analyze the frame PCs point-wise will cause
 	// the output to change between the go versions.
 	return parsePrintedStack(fmt.Sprintf("%+v", st))
 }
 
-// trimPath is a copy of the same function in package sentry-go.
+// anyFrameResolved reports whether the symbolication resolved at
+// least one frame to a known function.
+func anyFrameResolved(st *ReportableStackTrace) bool {
+	for _, f := range st.Frames {
+		if f.Symbol != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// trimPath is a copy of the same function in package sentry-go,
+// extended to handle Windows-style paths.
 func trimPath(filename string) string {
 	for _, prefix := range trimPaths {
 		if trimmed := strings.TrimPrefix(filename, prefix); len(trimmed) < len(filename) {
 			return trimmed
 		}
 	}
+	// Stacks captured by binaries built on Windows come through with
+	// backslash separators and drive-letter prefixes, which the
+	// SrcDirs-derived prefixes above never match. Normalize the
+	// separators, then trim at the last "src" component (GOPATH
+	// layout) or modules-cache root, so the result is the same clean
+	// module-relative path as on POSIX.
+	if strings.IndexByte(filename, '\\') != -1 {
+		norm := strings.Replace(filename, "\\", "/", -1)
+		if idx := strings.LastIndex(norm, "/src/"); idx != -1 {
+			return norm[idx+len("/src/"):]
+		}
+		if idx := strings.LastIndex(norm, "/pkg/mod/"); idx != -1 {
+			return norm[idx+len("/pkg/mod/"):]
+		}
+		return norm
+	}
 	return filename
 }
 
@@ -115,7 +196,18 @@ func functionName(fnName string) (pack string, name string) {
 	// and want this:
 	//  pack = runtime/debug
 	//	name = *T.ptrmethod
-	if idx := strings.LastIndex(name, "."); idx != -1 {
+	//
+	// Generic instantiations print as e.g. pkg.Foo[go.shape.int] or
+	// pkg.Recv[...].method: the bracketed type arguments can contain
+	// dots themselves and the method name follows the brackets, so the
+	// package/function split is on the last dot before the first
+	// bracket, and the function name keeps the brackets and any
+	// trailing method.
+	limit := len(name)
+	if idx := strings.IndexByte(name, '['); idx != -1 {
+		limit = idx
+	}
+	if idx := strings.LastIndex(name[:limit], "."); idx != -1 {
 		pack = name[:idx]
 		name = name[idx+1:]
 	}
@@ -123,6 +215,40 @@ func functionName(fnName string) (pack string, name string) {
 	return
 }
 
+// printedStackGoVersionPrefix heads a flattened stack payload to
+// record which Go runtime printed it. See (*withStack).SafeDetails().
+// The literal lives in errbase so that it can also be recognized
+// there (e.g. by EncodeErrorLimited) without an import cycle.
+const printedStackGoVersionPrefix = errbase.StackDetailGoVersionPrefix
+
+// IsStackDetail reports whether the given safe detail string is a
+// flattened stack trace payload, as produced by the SafeDetails()
+// method of the errors in this package. Other packages can use this
+// to carry a stack payload in their own safe details (e.g. a barrier
+// surfacing the stack of the error it masks) and have it recognized
+// by GetReportableStackTrace().
+func IsStackDetail(detail string) bool {
+	return strings.HasPrefix(detail, printedStackGoVersionPrefix)
+}
+
+// RetainStackDetail marks a flattened stack payload for carriage in
+// the safe details of an error type other than the one that captured
+// it. GetReportableStackTrace() only extracts a stack from a foreign
+// error type's details when this marker is present; see
+// getReportableStackTrace().
+func RetainStackDetail(detail string) string {
+	return errbase.StackDetailRetainedPrefix + detail
+}
+
+// RetainedStackDetail strips the marker applied by RetainStackDetail,
+// reporting whether it was present.
+func RetainedStackDetail(detail string) (string, bool) {
+	if strings.HasPrefix(detail, errbase.StackDetailRetainedPrefix) {
+		return detail[len(errbase.StackDetailRetainedPrefix):], true
+	}
+	return detail, false
+}
+
 // parsePrintedStack reverse-engineers a reportable stack trace from
 // the result of printing a github.com/pkg/errors stack trace with format %+v.
 func parsePrintedStack(st string) *ReportableStackTrace {
@@ -134,7 +260,21 @@ func parsePrintedStack(st string) *ReportableStackTrace {
 	// It's also likely to contain a heading newline character(s).
 	var frames []frame
 	lines := strings.Split(strings.TrimSpace(st), "\n")
+	if len(lines) > 0 && strings.HasPrefix(lines[0], printedStackGoVersionPrefix) {
+		// The payload records the Go version that produced it. All the
+		// versions supported so far (go1.12 and later) share the same
+		// printed format, so the header currently only needs to be
+		// skipped; it is the extension point to select different
+		// parsing rules should the format diverge again.
+		lines = lines[1:]
+	}
 	for i := 0; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "" {
+			// A blank line carries no entry; skipping it (instead of
+			// taking it as a function name) keeps the following
+			// entries aligned.
+			continue
+		}
 		nextI, file, line, fnName := parsePrintedStackEntry(lines, i)
 		i = nextI
 
@@ -148,10 +288,19 @@ func parsePrintedStack(st string) *ReportableStackTrace {
 			Function: fnName,
 		}
 		if fnName != "unknown" {
+			// Keep the original symbol (middle-dot preserved, no
+			// demangling) in the Symbol field: this is the
+			// "raw_function" side of Sentry's raw_function/function
+			// split, while Function carries the display form.
+			frame.Symbol = fnName
 			// Extract the function/module details.
 			frame.Module, frame.Function = functionName(fnName)
+			frame.Function = truncateName(demangle(frame.Function))
+			frame.Module = truncateName(frame.Module)
 		}
-		frames = append(frames, frame)
+		frame.InApp = computeInApp(frame.Module, frame.Filename)
+		frame.Package = packageLabel(frame.Module)
+		frames = append(frames, enrich(frame))
 	}
 
 	if frames == nil {
@@ -169,29 +318,49 @@ func parsePrintedStack(st string) *ReportableStackTrace {
 // parsePrintedStackEntry extracts the stack entry information
 // in lines at position i. It returns the new value of i if more than
 // one line was read.
+//
+// An entry normally spans two lines: the function name, then a
+// tab-indented "file:line" location. Both halves are optional: an
+// entry without a location line yields a frame with an unknown
+// location, and a location line without a preceding name line yields
+// a frame with an unknown function, so a degenerate entry never
+// consumes the next entry's lines.
 func parsePrintedStackEntry(
 	lines []string, i int,
 ) (newI int, file string, line int, fnName string) {
+	if strings.HasPrefix(lines[i], "\t") {
+		// A location line without a function name line before it.
+		fnName = "unknown"
+		file, line = parsePrintedFileLine(lines[i])
+		return i, file, line, fnName
+	}
+
 	// The function name is on the first line.
 	fnName = lines[i]
 
 	// The file:line pair may be on the line after that.
 	if i < len(lines)-1 && strings.HasPrefix(lines[i+1], "\t") {
-		fileLine := strings.TrimSpace(lines[i+1])
-		// Separate file path and line number.
-		lineSep := strings.LastIndexByte(fileLine, ':')
-		if lineSep == -1 {
-			file = fileLine
-		} else {
-			file = fileLine[:lineSep]
-			lineStr := fileLine[lineSep+1:]
-			line, _ = strconv.Atoi(lineStr)
-		}
+		file, line = parsePrintedFileLine(lines[i+1])
 		i++
 	}
 	return i, file, line, fnName
 }
 
+// parsePrintedFileLine separates the file path and line number in the
+// tab-indented location line of a printed stack entry.
+func parsePrintedFileLine(s string) (file string, line int) {
+	fileLine := strings.TrimSpace(s)
+	lineSep := strings.LastIndexByte(fileLine, ':')
+	if lineSep == -1 {
+		file = fileLine
+	} else {
+		file = fileLine[:lineSep]
+		lineStr := fileLine[lineSep+1:]
+		line, _ = strconv.Atoi(lineStr)
+	}
+	return file, line
+}
+
 var pkgFundamental errbase.TypeKey
 var pkgWithStackName errbase.TypeKey
 var ourWithStackName errbase.TypeKey
@@ -0,0 +1,81 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/errors/testutils"
+)
+
+// TestParsePrintedStackAlignment checks that degenerate entries in a
+// printed stack payload - "unknown" markers and entries without a
+// location line - do not shift the parse of the entries that follow.
+func TestParsePrintedStackAlignment(t *testing.T) {
+	tt := testutils.T{T: t}
+
+	st := parsePrintedStack("foo.com/pkg.FnA\n" +
+		"\t/src/foo/a.go:10\n" +
+		"unknown\n" +
+		"foo.com/pkg.FnB\n" +
+		"\t/src/foo/b.go:20\n" +
+		"foo.com/pkg.FnTrailing")
+	tt.Assert(st != nil)
+	tt.AssertEqual(len(st.Frames), 4)
+
+	// The frames come out oldest first.
+	tt.CheckEqual(st.Frames[0].Function, "FnTrailing")
+	tt.CheckEqual(st.Frames[0].AbsPath, "")
+
+	tt.CheckEqual(st.Frames[1].Function, "FnB")
+	tt.CheckEqual(st.Frames[1].AbsPath, "/src/foo/b.go")
+	tt.CheckEqual(st.Frames[1].Lineno, 20)
+
+	tt.CheckEqual(st.Frames[2].Function, "unknown")
+	tt.CheckEqual(st.Frames[2].Lineno, 0)
+
+	tt.CheckEqual(st.Frames[3].Function, "FnA")
+	tt.CheckEqual(st.Frames[3].AbsPath, "/src/foo/a.go")
+	tt.CheckEqual(st.Frames[3].Lineno, 10)
+}
+
+// TestParsePrintedStackDegenerateLines checks the handling of the
+// non-entry lines a payload may contain: the Go version header, blank
+// lines, and a tab-indented location line without a name line before
+// it.
+func TestParsePrintedStackDegenerateLines(t *testing.T) {
+	tt := testutils.T{T: t}
+
+	st := parsePrintedStack(printedStackGoVersionPrefix + "go1.21\n" +
+		"\n" +
+		"foo.com/pkg.FnA\n" +
+		"\t/src/foo/a.go:10\n" +
+		"\t/src/foo/orphan.go:33")
+	tt.Assert(st != nil)
+	tt.AssertEqual(len(st.Frames), 2)
+
+	// The orphan location line yields a frame of its own, with an
+	// unknown function, instead of being absorbed into FnA's entry.
+	tt.CheckEqual(st.Frames[0].Function, "unknown")
+	tt.CheckEqual(st.Frames[0].AbsPath, "/src/foo/orphan.go")
+	tt.CheckEqual(st.Frames[0].Lineno, 33)
+
+	tt.CheckEqual(st.Frames[1].Function, "FnA")
+	tt.CheckEqual(st.Frames[1].AbsPath, "/src/foo/a.go")
+	tt.CheckEqual(st.Frames[1].Lineno, 10)
+
+	// A header-only payload has no frames at all.
+	tt.Check(parsePrintedStack(printedStackGoVersionPrefix+"go1.21") == nil)
+}
@@ -0,0 +1,43 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+// ReRootStack returns a copy of the given stack trace re-rooted at
+// the named entry function: all frames older than that function are
+// dropped, making it the new oldest frame. This gives per-subsystem
+// reports a clean root without the shared bootstrap frames beneath
+// the entry point.
+//
+// The entry function is matched against both the bare function name
+// and the fully-qualified "module.function" form. If the function
+// appears multiple times in the stack (e.g. under recursion), the
+// oldest occurrence wins, preserving as many frames as possible. The
+// input is returned unchanged when the entry function does not appear
+// in the stack, and nil input yields nil.
+func ReRootStack(st *ReportableStackTrace, entryFunc string) *ReportableStackTrace {
+	if st == nil {
+		return nil
+	}
+	// The frames are stored oldest-first: scan from the start to find
+	// the oldest occurrence.
+	for i, f := range st.Frames {
+		if f.Function == entryFunc || f.Module+"."+f.Function == entryFunc {
+			frames := make([]frame, len(st.Frames)-i)
+			copy(frames, st.Frames[i:])
+			return &ReportableStackTrace{Frames: frames}
+		}
+	}
+	return st
+}
@@ -0,0 +1,69 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+import "runtime"
+
+// runtimeFramesProvider matches error types from third-party
+// libraries that attach a stack trace as already-resolved
+// runtime.Frame values, instead of the github.com/pkg/errors
+// StackTrace recognized by errbase.StackTraceProvider.
+type runtimeFramesProvider interface {
+	StackTrace() []runtime.Frame
+}
+
+// convertRuntimeFrames converts a list of resolved runtime.Frame
+// values (newest call first, as produced by runtime.CallersFrames)
+// to a Stacktrace in github.com/getsentry/sentry-go.
+//
+// Note: Sentry wants the oldest call frame first, so
+// the entries are reversed in the result.
+func convertRuntimeFrames(rfs []runtime.Frame) *ReportableStackTrace {
+	// If there are no frames, the entire stacktrace is nil.
+	if len(rfs) == 0 {
+		return nil
+	}
+
+	frames := make([]frame, 0, len(rfs))
+	for _, f := range rfs {
+		fr := frame{
+			AbsPath:  f.File,
+			Filename: trimPath(f.File),
+			Lineno:   f.Line,
+			InApp:    true,
+			Module:   "unknown",
+			Function: "unknown",
+		}
+		if f.Function != "" {
+			// Keep the original runtime symbol in the Symbol field;
+			// Function below carries the display form.
+			fr.Symbol = f.Function
+			// Extract the function/module details.
+			fr.Module, fr.Function = functionName(f.Function)
+			fr.Function = truncateName(demangle(fr.Function))
+			fr.Module = truncateName(fr.Module)
+		}
+		fr.InApp = computeInApp(fr.Module, fr.Filename)
+		fr.Package = packageLabel(fr.Module)
+		frames = append(frames, enrich(fr))
+	}
+
+	// Sentry wants the frames with the oldest first, so reverse them.
+	for i, j := 0, len(frames)-1; i < j; i, j = i+1, j-1 {
+		frames[i], frames[j] = frames[j], frames[i]
+	}
+
+	return &ReportableStackTrace{Frames: frames}
+}
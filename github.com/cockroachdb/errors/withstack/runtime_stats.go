@@ -0,0 +1,71 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+import (
+	"runtime"
+
+	"github.com/cockroachdb/errors/errbase"
+)
+
+// captureRuntimeStats is set via CaptureRuntimeStats().
+var captureRuntimeStats = false
+
+// CaptureRuntimeStats enables or disables the recording of a small
+// runtime statistics snapshot (goroutine count, heap allocation) by
+// WithStack() and WithStackDepth() at capture time. The snapshot can
+// be read back with GetRuntimeStatsAtCapture().
+//
+// This helps correlate certain errors (e.g. OOM-adjacent failures)
+// with resource pressure at the time they occurred. The feature is
+// disabled by default since the snapshot has a cost; it is additive
+// metadata and does not influence GetReportableStackTrace(). The
+// snapshot is process-local: it does not travel across the network
+// with the error.
+func CaptureRuntimeStats(enable bool) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	captureRuntimeStats = enable
+}
+
+func getCaptureRuntimeStats() bool {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return captureRuntimeStats
+}
+
+// snapshotRuntimeStats collects the capture-time statistics.
+func snapshotRuntimeStats() map[string]uint64 {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	return map[string]uint64{
+		"num_goroutine": uint64(runtime.NumGoroutine()),
+		"heap_alloc":    ms.HeapAlloc,
+		"heap_objects":  ms.HeapObjects,
+	}
+}
+
+// GetRuntimeStatsAtCapture returns the runtime statistics snapshot
+// recorded when the stack trace in err was captured, if any (see
+// CaptureRuntimeStats). The outermost capture in the chain wins,
+// mirroring GetReportableStackTrace().
+func GetRuntimeStatsAtCapture(err error) (map[string]uint64, bool) {
+	for c := err; c != nil; c = errbase.UnwrapOnce(c) {
+		if w, ok := c.(*withStack); ok && w.runtimeStats != nil {
+			return w.runtimeStats, true
+		}
+	}
+	return nil, false
+}
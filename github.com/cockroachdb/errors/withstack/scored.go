@@ -0,0 +1,93 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+import "strings"
+
+// ScoredFrame pairs a reportable stack frame with a confidence score
+// estimating how likely the frame belongs to application code (as
+// opposed to library or runtime code).
+type ScoredFrame struct {
+	Frame
+
+	// Confidence is a heuristic estimate, in [0,1], that the frame is
+	// application code. It refines the coarse boolean InApp flag:
+	// standard library frames score near zero, vendored dependencies
+	// score low, and shallow non-vendored modules score high.
+	Confidence float64
+}
+
+// GetScoredStackTrace extracts the stack trace embedded in the given
+// error (like GetReportableStackTrace) and annotates each frame with
+// a "library vs application" confidence score.
+//
+// The score is a heuristic based on the shape of the module path
+// (standard library modules have no domain component), vendoring,
+// module path depth and test-file detection. Consumers can use it to
+// weigh frames continuously, e.g. when selecting fingerprint frames,
+// instead of relying on the boolean InApp flag.
+//
+// The frame ordering is that of GetReportableStackTrace (oldest
+// first). The result is nil if no stack trace is available.
+func GetScoredStackTrace(err error) []ScoredFrame {
+	st := GetReportableStackTrace(err)
+	if st == nil {
+		return nil
+	}
+	scored := make([]ScoredFrame, len(st.Frames))
+	for i, f := range st.Frames {
+		scored[i] = ScoredFrame{Frame: f, Confidence: scoreFrame(f)}
+	}
+	return scored
+}
+
+// scoreFrame computes the application-code confidence for one frame.
+func scoreFrame(f Frame) float64 {
+	firstSeg := f.Module
+	if i := strings.IndexByte(firstSeg, '/'); i >= 0 {
+		firstSeg = firstSeg[:i]
+	}
+	switch {
+	case f.Module == "unknown":
+		// No module information: no opinion either way.
+		return 0.5
+	case !strings.Contains(firstSeg, "."):
+		// Standard library / runtime: the first path segment carries no
+		// domain name.
+		return 0.05
+	case strings.Contains(f.Module, "/vendor/") || strings.Contains(f.Filename, "/vendor/"):
+		// Vendored third-party code.
+		return 0.2
+	}
+	// Non-vendored module with a domain name. Start high and penalize
+	// deeply nested module paths, which tend to be transitive
+	// dependencies rather than the application's own packages.
+	score := 0.8
+	depth := strings.Count(f.Module, "/")
+	if depth > 2 {
+		score -= 0.1 * float64(depth-2)
+	}
+	if strings.HasSuffix(f.Filename, "_test.go") {
+		// Test files are almost always the application's own code.
+		score += 0.15
+	}
+	if score < 0.3 {
+		score = 0.3
+	}
+	if score > 1 {
+		score = 1
+	}
+	return score
+}
@@ -0,0 +1,52 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+// StackWithSites carries a reportable stack trace together with the
+// two frames whose semantics consumers most often need: where the
+// call path started and where the stack was captured.
+type StackWithSites struct {
+	// Stack is the underlying reportable stack trace, with the usual
+	// oldest-first ordering.
+	Stack *ReportableStackTrace
+	// Origin is the oldest frame: where the reported call path
+	// started ("where it happened").
+	Origin Frame
+	// WrapSite is the newest frame: where the stack was captured,
+	// e.g. the WithStack() call site ("where it was wrapped"). For a
+	// stack captured at the point the error was created, this
+	// coincides with the creation site.
+	WrapSite Frame
+}
+
+// GetReportableStackTraceWithSites extracts the stack trace embedded
+// in the given error (like GetReportableStackTrace) and additionally
+// identifies the origin frame and the wrap-site frame explicitly, so
+// that consumers need not rely on index conventions to distinguish
+// "where it happened" from "where it was wrapped".
+//
+// The boolean return is false when no stack trace (or an empty one)
+// is available.
+func GetReportableStackTraceWithSites(err error) (StackWithSites, bool) {
+	st := GetReportableStackTrace(err)
+	if st == nil || len(st.Frames) == 0 {
+		return StackWithSites{}, false
+	}
+	return StackWithSites{
+		Stack:    st,
+		Origin:   st.Frames[0],
+		WrapSite: st.Frames[len(st.Frames)-1],
+	}, true
+}
@@ -0,0 +1,54 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build go1.21
+// +build go1.21
+
+package withstack
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// StackLogValue renders the stack trace embedded in the given error
+// as a structured slog value: a group containing the origin location
+// and a "frames" list, each frame rendered as "module.function
+// (file:line)" newest-first.
+//
+// This allows logging a stack with any slog handler as:
+//
+//	logger.Error("request failed", slog.Any("stack", withstack.StackLogValue(err)))
+//
+// An empty group is returned when no stack trace is available.
+func StackLogValue(err error) slog.Value {
+	st := GetReportableStackTrace(err)
+	if st == nil || len(st.Frames) == 0 {
+		return slog.GroupValue()
+	}
+	origin := st.Frames[0]
+	frames := make([]string, 0, len(st.Frames))
+	// The frames are stored oldest-first; log them newest-first.
+	for i := len(st.Frames) - 1; i >= 0; i-- {
+		f := st.Frames[i]
+		frames = append(frames, fmt.Sprintf("%s.%s (%s:%d)",
+			f.Module, f.Function, f.Filename, f.Lineno))
+	}
+	return slog.GroupValue(
+		slog.String("origin_file", origin.Filename),
+		slog.Int("origin_line", origin.Lineno),
+		slog.String("origin_function", origin.Function),
+		slog.Any("frames", frames),
+	)
+}
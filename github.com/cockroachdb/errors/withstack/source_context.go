@@ -0,0 +1,117 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+import (
+	"bufio"
+	"io"
+	"os"
+)
+
+// sourceContextResolver, when set, maps a frame's absolute path to a
+// readable local path. See SetSourceContextResolver().
+var sourceContextResolver func(absPath string) string
+
+// sourceContextLines is the number of lines captured on each side of
+// a frame's own line.
+const sourceContextLines = 3
+
+// maxSourceReadBytes bounds how much of a source file is read while
+// looking for a frame's context lines, so a frame pointing deep into
+// a huge (or corrupt) file cannot make report construction expensive.
+const maxSourceReadBytes = 512 * 1024
+
+// SetSourceContextResolver enables source snippet capture on
+// reportable stack traces. When a resolver is registered, every
+// resolved frame gets its PreContext, ContextLine and PostContext
+// fields populated from the source file, which lets Sentry display
+// the offending lines alongside the trace.
+//
+// The resolver maps a frame's absolute path, as recorded at build
+// time, to a path readable in the current environment (e.g. by
+// re-rooting it into an unpacked source archive). It returns the
+// empty string for frames whose source is not available - vendored or
+// standard library files, say - and those frames are simply left
+// without context lines, as are frames whose resolved file cannot be
+// opened.
+//
+// This is opt-in (the default is to capture nothing) because
+// production binaries usually do not ship their source. At most
+// maxSourceReadBytes are read per file.
+//
+// Like the other configuration knobs in this package, this is safe to
+// call concurrently with stack trace extraction, though it is best
+// called once during initialization.
+func SetSourceContextResolver(fn func(absPath string) string) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	sourceContextResolver = fn
+}
+
+// addSourceContext fills the frame's context-line fields from its
+// source file, when a resolver is registered and the source can be
+// found.
+func addSourceContext(f Frame) Frame {
+	configMu.RLock()
+	resolve := sourceContextResolver
+	configMu.RUnlock()
+	if resolve == nil || f.AbsPath == "" || f.Lineno <= 0 {
+		return f
+	}
+	path := resolve(f.AbsPath)
+	if path == "" {
+		return f
+	}
+	pre, line, post, ok := readSourceContext(path, f.Lineno)
+	if !ok {
+		return f
+	}
+	f.PreContext = pre
+	f.ContextLine = line
+	f.PostContext = post
+	return f
+}
+
+// readSourceContext reads the line at the given position in the file,
+// with sourceContextLines lines on each side. ok is false if the file
+// cannot be read or is too short (or too large, see
+// maxSourceReadBytes) for the line to be reached.
+func readSourceContext(path string, lineno int) (pre []string, line string, post []string, ok bool) {
+	fh, err := os.Open(path)
+	if err != nil {
+		return nil, "", nil, false
+	}
+	defer func() { _ = fh.Close() }()
+
+	sc := bufio.NewScanner(io.LimitReader(fh, maxSourceReadBytes))
+	n := 0
+	for sc.Scan() {
+		n++
+		switch {
+		case n < lineno-sourceContextLines:
+			// Not there yet.
+		case n < lineno:
+			pre = append(pre, sc.Text())
+		case n == lineno:
+			line = sc.Text()
+			ok = true
+		case n <= lineno+sourceContextLines:
+			post = append(post, sc.Text())
+		default:
+			return pre, line, post, ok
+		}
+	}
+	return pre, line, post, ok
+}
@@ -0,0 +1,137 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cockroachdb/errors/errbase"
+	"github.com/gogo/protobuf/proto"
+)
+
+// WithSpanContext annotates err with the identifiers of the tracing
+// span that was active when the error was created, so that error
+// reports can link back to the distributed trace. The identifiers
+// must be PII-free.
+//
+// Detail is shown:
+// - via `errors.GetSafeDetails()`.
+// - via `GetSpanContext()` below.
+// - when formatting with `%+v`.
+// - in Sentry reports, and in the reportable stack metadata
+//   when enabled via AttachSpanContextToStacks().
+func WithSpanContext(err error, traceID, spanID string) error {
+	if err == nil {
+		return nil
+	}
+	return &withSpanContext{cause: err, traceID: traceID, spanID: spanID}
+}
+
+// GetSpanContext retrieves the span context attached to the error via
+// WithSpanContext(), if any. The outermost annotation wins.
+func GetSpanContext(err error) (traceID, spanID string, ok bool) {
+	for ; err != nil; err = errbase.UnwrapOnce(err) {
+		if w, ok := err.(*withSpanContext); ok {
+			return w.traceID, w.spanID, true
+		}
+	}
+	return "", "", false
+}
+
+// attachSpanContextToStacks is set via AttachSpanContextToStacks().
+var attachSpanContextToStacks = false
+
+// AttachSpanContextToStacks enables or disables the attachment of
+// span contexts (see WithSpanContext) to the stack traces extracted
+// by GetReportableStackTrace(). When enabled, the identifiers are
+// stored under the "span_trace_id" and "span_id" keys in the Vars map
+// of the topmost frame.
+//
+// The feature is disabled by default, in which case the output of
+// GetReportableStackTrace() is unchanged.
+func AttachSpanContextToStacks(enable bool) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	attachSpanContextToStacks = enable
+}
+
+func getAttachSpanContextToStacks() bool {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return attachSpanContextToStacks
+}
+
+// maybeAttachSpanContext implements AttachSpanContextToStacks on the
+// result of an extraction.
+func maybeAttachSpanContext(st *ReportableStackTrace, err error) *ReportableStackTrace {
+	if !getAttachSpanContextToStacks() || st == nil || len(st.Frames) == 0 {
+		return st
+	}
+	traceID, spanID, ok := GetSpanContext(err)
+	if !ok {
+		return st
+	}
+	top := &st.Frames[len(st.Frames)-1]
+	if top.Vars == nil {
+		top.Vars = map[string]interface{}{}
+	}
+	top.Vars["span_trace_id"] = traceID
+	top.Vars["span_id"] = spanID
+	return st
+}
+
+type withSpanContext struct {
+	cause   error
+	traceID string
+	spanID  string
+}
+
+var _ error = (*withSpanContext)(nil)
+var _ errbase.SafeDetailer = (*withSpanContext)(nil)
+var _ fmt.Formatter = (*withSpanContext)(nil)
+var _ errbase.Formatter = (*withSpanContext)(nil)
+
+func (w *withSpanContext) Error() string { return w.cause.Error() }
+func (w *withSpanContext) Cause() error  { return w.cause }
+func (w *withSpanContext) Unwrap() error { return w.cause }
+
+func (w *withSpanContext) SafeDetails() []string { return []string{w.traceID, w.spanID} }
+
+func (w *withSpanContext) Format(s fmt.State, verb rune) { errbase.FormatError(w, s, verb) }
+
+func (w *withSpanContext) FormatError(p errbase.Printer) (next error) {
+	if p.Detail() {
+		p.Printf("span: %s/%s", w.traceID, w.spanID)
+	}
+	return w.cause
+}
+
+func decodeWithSpanContext(
+	_ context.Context, cause error, _ string, details []string, _ proto.Message,
+) error {
+	var traceID, spanID string
+	if len(details) > 0 {
+		traceID = details[0]
+	}
+	if len(details) > 1 {
+		spanID = details[1]
+	}
+	return &withSpanContext{cause: cause, traceID: traceID, spanID: spanID}
+}
+
+func init() {
+	errbase.RegisterWrapperDecoder(errbase.GetTypeKey((*withSpanContext)(nil)), decodeWithSpanContext)
+}
@@ -48,12 +48,17 @@ func (s *stack) StackTrace() errbase.StackTrace {
 	return f
 }
 
-// callers mirrors the code in github.com/pkg/errors,
-// but makes the depth customizable.
-func callers(depth int) *stack {
+// callersLimit mirrors the callers() code in github.com/pkg/errors,
+// but makes the depth customizable and limits the number of captured
+// frames to limit when it is positive.
+func callersLimit(depth, limit int) *stack {
 	const numFrames = 32
-	var pcs [numFrames]uintptr
-	n := runtime.Callers(2+depth, pcs[:])
+	sz := numFrames
+	if limit > 0 && limit < sz {
+		sz = limit
+	}
+	pcs := make([]uintptr, sz)
+	n := runtime.Callers(2+depth, pcs)
 	var st stack = pcs[0:n]
 	return &st
 }
@@ -0,0 +1,61 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+import (
+	"time"
+
+	"github.com/cockroachdb/errors/errbase"
+)
+
+// captureTimestamps, when set, makes WithStack() and WithStackDepth()
+// record the time at which the stack trace was captured.
+var captureTimestamps = false
+
+// CaptureTimestamps enables or disables the recording of a capture
+// timestamp alongside stack traces produced by WithStack() and
+// WithStackDepth(). The timestamp can be read back with GetStackAge().
+//
+// The feature is disabled by default. The timestamp is process-local
+// metadata: it does not travel across the network with the error.
+func CaptureTimestamps(enable bool) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	captureTimestamps = enable
+}
+
+func getCaptureTimestamps() bool {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return captureTimestamps
+}
+
+// GetStackAge returns the time elapsed since the stack trace in err
+// was captured. The boolean return is false if err carries no stack
+// trace, or the stack trace was captured without a timestamp (see
+// CaptureTimestamps), or the error was transferred from another
+// process.
+//
+// The outermost stack capture wins, mirroring the extraction order of
+// GetReportableStackTrace(). This can be used to spot errors that sit
+// un-reported for too long.
+func GetStackAge(err error) (time.Duration, bool) {
+	for c := err; c != nil; c = errbase.UnwrapOnce(c) {
+		if w, ok := c.(*withStack); ok && !w.captureTime.IsZero() {
+			return time.Since(w.captureTime), true
+		}
+	}
+	return 0, false
+}
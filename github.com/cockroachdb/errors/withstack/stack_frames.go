@@ -0,0 +1,177 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+import (
+	"os"
+	"runtime"
+
+	"github.com/cockroachdb/errors/errbase"
+)
+
+// FrameInfo is a neutral representation of one resolved stack frame,
+// independent of the Sentry types used by ReportableStackTrace.
+type FrameInfo struct {
+	// Module is the import path of the package containing the
+	// function, possibly normalized or truncated (see e.g.
+	// SetMaxFunctionNameLength).
+	Module string
+	// FullModule is the complete, untruncated import path of the
+	// package, re-derived from the raw symbol when available. Use it
+	// e.g. for source linking, while Module remains suitable for
+	// grouping; both representations are produced in one extraction
+	// pass and stay consistent with each other.
+	FullModule string
+	// Function is the name of the function (possibly a method with
+	// its receiver).
+	Function string
+	// File is the (trimmed) path of the source file.
+	File string
+	// AbsPath is the untrimmed path of the source file.
+	AbsPath string
+	// Line is the line number in the source file.
+	Line int
+	// InApp indicates whether the frame was classified as application
+	// code.
+	InApp bool
+	// FrameIndex is a stable index counted from the origin of the
+	// stack: the oldest frame has index 0. Structurally identical
+	// stacks extracted at different times yield the same indices, so
+	// the index can be used to correlate a specific frame across
+	// multiple reports of the same error.
+	FrameIndex int
+	// SourceAvailable indicates whether the source file at AbsPath
+	// was found on disk at extraction time. It is only populated when
+	// enabled via VerifySourceExists(); it helps diagnose which
+	// frames will be able to get source-context lines in reports and
+	// which will not (e.g. vendored or stdlib files absent from the
+	// deployment).
+	SourceAvailable bool
+}
+
+// verifySourceExists is set via VerifySourceExists().
+var verifySourceExists = false
+
+// VerifySourceExists enables or disables the per-frame check, in
+// GetStackFrames(), that the frame's source file still exists on
+// disk (see FrameInfo.SourceAvailable). Disabled by default since it
+// performs one stat(2) call per frame.
+func VerifySourceExists(enable bool) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	verifySourceExists = enable
+}
+
+func getVerifySourceExists() bool {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return verifySourceExists
+}
+
+// GetStackFrames extracts the stack trace embedded in the given error
+// (like GetReportableStackTrace) as neutral FrameInfo values, oldest
+// frame first. The result is nil when no stack trace is available.
+func GetStackFrames(err error) []FrameInfo {
+	st := GetReportableStackTrace(err)
+	if st == nil {
+		return nil
+	}
+	verifySource := getVerifySourceExists()
+	frames := make([]FrameInfo, len(st.Frames))
+	for i, f := range st.Frames {
+		fullModule := f.Module
+		if f.Symbol != "" {
+			// The Symbol field carries the raw, untruncated symbol;
+			// re-derive the complete module path from it.
+			fullModule, _ = functionName(f.Symbol)
+		}
+		frames[i] = FrameInfo{
+			Module:     f.Module,
+			FullModule: fullModule,
+			Function:   f.Function,
+			File:       f.Filename,
+			AbsPath:    f.AbsPath,
+			Line:       f.Lineno,
+			InApp:      f.InApp,
+			FrameIndex: i,
+		}
+		if verifySource {
+			_, statErr := os.Stat(f.AbsPath)
+			frames[i].SourceAvailable = statErr == nil
+		}
+	}
+	return frames
+}
+
+// StackFrames extracts the stack trace embedded in the given error as
+// runtime.Frame values, in normal (newest call first) order, without
+// involving the Sentry types used by GetReportableStackTrace.
+//
+// For errors carrying raw program counters (e.g. local WithStack
+// errors), the frames are resolved via runtime.CallersFrames and thus
+// include PC/Entry information and expanded inlined calls. For errors
+// whose stack was flattened to text during network transfer, the
+// frames are reconstituted from the parsed representation and only
+// carry function, file and line information.
+//
+// The result is nil when no stack trace is present.
+func StackFrames(err error) []runtime.Frame {
+	// If we have raw PCs, resolve them directly.
+	if st, ok := err.(errbase.StackTraceProvider); ok {
+		pst := st.StackTrace()
+		if len(pst) == 0 {
+			return nil
+		}
+		pcs := make([]uintptr, len(pst))
+		for i, f := range pst {
+			pcs[i] = uintptr(f)
+		}
+		var frames []runtime.Frame
+		ci := runtime.CallersFrames(pcs)
+		for {
+			f, more := ci.Next()
+			frames = append(frames, f)
+			if !more {
+				break
+			}
+		}
+		return frames
+	}
+
+	// Otherwise, reconstitute frames from whatever representation the
+	// error carries (e.g. flattened safe details).
+	st := getReportableStackTrace(err)
+	if st == nil {
+		return nil
+	}
+	frames := make([]runtime.Frame, len(st.Frames))
+	for i, f := range st.Frames {
+		fnName := f.Symbol
+		if fnName == "" && f.Function != "" && f.Function != "unknown" {
+			fnName = f.Function
+			if f.Module != "" && f.Module != "unknown" {
+				fnName = f.Module + "." + f.Function
+			}
+		}
+		// The reportable frames are oldest first; restore the newest
+		// first order.
+		frames[len(st.Frames)-1-i] = runtime.Frame{
+			Function: fnName,
+			File:     f.AbsPath,
+			Line:     f.Lineno,
+		}
+	}
+	return frames
+}
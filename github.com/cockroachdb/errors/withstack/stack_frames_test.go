@@ -0,0 +1,58 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/errors/testutils"
+)
+
+// stackCapturer exists to capture a stack inside a method on a
+// pointer receiver.
+type stackCapturer struct{}
+
+//go:noinline
+func (c *stackCapturer) capture() error {
+	return WithStack(errors.New("hello"))
+}
+
+// TestGetStackFramesPointerReceiver checks that for a frame created
+// in a method on a pointer receiver, the method name and the
+// receiver-qualified module are split per this package's convention
+// (the receiver stays on the module side), and that Module and
+// FullModule stay consistent with each other.
+func TestGetStackFramesPointerReceiver(t *testing.T) {
+	tt := testutils.T{T: t}
+
+	var c stackCapturer
+	frames := GetStackFrames(c.capture())
+	tt.Assert(frames != nil)
+
+	found := false
+	for _, f := range frames {
+		if f.Function != "capture" {
+			continue
+		}
+		found = true
+		tt.CheckEqual(f.Module, "github.com/cockroachdb/errors/withstack.(*stackCapturer)")
+		tt.CheckEqual(f.FullModule, f.Module)
+		tt.Check(f.Line > 0)
+		tt.Check(strings.HasSuffix(f.AbsPath, "stack_frames_test.go"))
+	}
+	tt.Assert(found)
+}
@@ -0,0 +1,44 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+// StreamReportableFrames extracts the stack trace embedded in the
+// given error and emits its frames one at a time, in Sentry order
+// (oldest first), by calling the given emit function. This lets a
+// caller write frames to a streaming transport as they are consumed,
+// overlapping the downstream I/O with the remaining work.
+//
+// Note on buffering: stacks are captured newest-first, so producing
+// the oldest-first emission order inherently requires the frames to
+// be buffered and reversed once before the first emit call. That
+// reversal buffer is internal to the extraction; past it, the frames
+// are handed out one by one without further copies of the whole
+// stack.
+//
+// Emission stops at the first error returned by emit, which is then
+// returned. The function returns nil (and emits nothing) when no
+// stack trace is available.
+func StreamReportableFrames(err error, emit func(Frame) error) error {
+	st := GetReportableStackTrace(err)
+	if st == nil {
+		return nil
+	}
+	for _, f := range st.Frames {
+		if err := emit(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
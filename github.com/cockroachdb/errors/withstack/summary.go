@@ -0,0 +1,40 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+import "strings"
+
+// GetStackSummary produces a one-line summary of the stack trace
+// embedded in the given error: the newest n frames rendered as
+// "fnA <- fnB <- fnC", each as module.function.
+//
+// This is suitable e.g. as an alert title: it conveys the call path
+// concisely and remains stable across occurrences of the same error.
+//
+// Stacks shorter than n produce fewer entries; the result is the
+// empty string when no stack trace is available.
+func GetStackSummary(err error, n int) string {
+	st := GetReportableStackTrace(err)
+	if st == nil || n <= 0 {
+		return ""
+	}
+	var parts []string
+	// The frames are stored oldest-first; the newest ones are at the
+	// end of the slice.
+	for i := len(st.Frames) - 1; i >= 0 && len(parts) < n; i-- {
+		parts = append(parts, FullyQualifiedFunction(st.Frames[i]))
+	}
+	return strings.Join(parts, " <- ")
+}
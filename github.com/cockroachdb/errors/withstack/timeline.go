@@ -0,0 +1,70 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TimelineEntry is one step in the result of BuildCausalTimeline.
+type TimelineEntry struct {
+	// Index is the position of the error in the input slice,
+	// counted from zero.
+	Index int
+	// Stack is the stack trace extracted from that error, or nil when
+	// the error carries no stack.
+	Stack *ReportableStackTrace
+}
+
+// BuildCausalTimeline extracts the stack traces embedded in each of
+// the given errors (like GetReportableStackTrace) and returns them in
+// the provided order, labeled with their position. This gives e.g. a
+// retry loop that accumulated one error per attempt a single
+// chronological view, instead of one opaque final error.
+//
+// Errors without a stack trace still get an entry, with a nil Stack,
+// so the indices always line up with the input. Nil input yields a nil
+// result.
+func BuildCausalTimeline(errs []error) []TimelineEntry {
+	if errs == nil {
+		return nil
+	}
+	entries := make([]TimelineEntry, len(errs))
+	for i, err := range errs {
+		entries[i] = TimelineEntry{Index: i, Stack: GetReportableStackTrace(err)}
+	}
+	return entries
+}
+
+// FormatCausalTimeline renders the result of BuildCausalTimeline as
+// text, one "Attempt N:" heading per entry (numbered from 1) followed
+// by the frames of that entry's stack, newest call first. Entries
+// without a stack render as "Attempt N: no stack trace".
+func FormatCausalTimeline(entries []TimelineEntry) string {
+	var buf strings.Builder
+	for _, e := range entries {
+		if e.Stack == nil {
+			fmt.Fprintf(&buf, "Attempt %d: no stack trace\n", e.Index+1)
+			continue
+		}
+		fmt.Fprintf(&buf, "Attempt %d:\n", e.Index+1)
+		for i := len(e.Stack.Frames) - 1; i >= 0; i-- {
+			f := e.Stack.Frames[i]
+			fmt.Fprintf(&buf, "%s.%s\n\t%s:%d\n", f.Module, f.Function, f.Filename, f.Lineno)
+		}
+	}
+	return buf.String()
+}
@@ -0,0 +1,132 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cockroachdb/errors/errbase"
+	"github.com/gogo/protobuf/proto"
+)
+
+// WithTraceID annotates err with a logical trace ID, so that error
+// reports can be correlated with distributed traces. The trace ID
+// must be PII-free.
+//
+// Detail is shown:
+// - via `errors.GetSafeDetails()`.
+// - via `GetTraceID()` below.
+// - when formatting with `%+v`.
+// - in Sentry reports, and in the reportable stack metadata
+//   when enabled via AttachTraceIDToStacks().
+func WithTraceID(err error, traceID string) error {
+	if err == nil {
+		return nil
+	}
+	return &withTraceID{cause: err, traceID: traceID}
+}
+
+// GetTraceID retrieves the trace ID attached to the error via
+// WithTraceID(), if any. The outermost annotation wins.
+func GetTraceID(err error) (string, bool) {
+	for ; err != nil; err = errbase.UnwrapOnce(err) {
+		if w, ok := err.(*withTraceID); ok {
+			return w.traceID, true
+		}
+	}
+	return "", false
+}
+
+// attachTraceIDToStacks is set via AttachTraceIDToStacks().
+var attachTraceIDToStacks = false
+
+// AttachTraceIDToStacks enables or disables the attachment of trace
+// IDs (see WithTraceID) to the stack traces extracted by
+// GetReportableStackTrace(). When enabled, the trace ID is stored
+// under the "trace_id" key in the Vars map of the topmost frame.
+//
+// The feature is disabled by default, in which case the output of
+// GetReportableStackTrace() is unchanged.
+func AttachTraceIDToStacks(enable bool) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	attachTraceIDToStacks = enable
+}
+
+func getAttachTraceIDToStacks() bool {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return attachTraceIDToStacks
+}
+
+// maybeAttachTraceID implements AttachTraceIDToStacks on the result
+// of an extraction.
+func maybeAttachTraceID(st *ReportableStackTrace, err error) *ReportableStackTrace {
+	if !getAttachTraceIDToStacks() || st == nil || len(st.Frames) == 0 {
+		return st
+	}
+	tid, ok := GetTraceID(err)
+	if !ok {
+		return st
+	}
+	// The topmost (newest) frame is the last one; see the ordering
+	// note in GetReportableStackTrace().
+	top := &st.Frames[len(st.Frames)-1]
+	if top.Vars == nil {
+		top.Vars = map[string]interface{}{}
+	}
+	top.Vars["trace_id"] = tid
+	return st
+}
+
+type withTraceID struct {
+	cause   error
+	traceID string
+}
+
+var _ error = (*withTraceID)(nil)
+var _ errbase.SafeDetailer = (*withTraceID)(nil)
+var _ fmt.Formatter = (*withTraceID)(nil)
+var _ errbase.Formatter = (*withTraceID)(nil)
+
+func (w *withTraceID) Error() string { return w.cause.Error() }
+func (w *withTraceID) Cause() error  { return w.cause }
+func (w *withTraceID) Unwrap() error { return w.cause }
+
+func (w *withTraceID) SafeDetails() []string { return []string{w.traceID} }
+
+func (w *withTraceID) Format(s fmt.State, verb rune) { errbase.FormatError(w, s, verb) }
+
+func (w *withTraceID) FormatError(p errbase.Printer) (next error) {
+	if p.Detail() {
+		p.Printf("trace ID: %s", w.traceID)
+	}
+	return w.cause
+}
+
+func decodeWithTraceID(
+	_ context.Context, cause error, _ string, details []string, _ proto.Message,
+) error {
+	var traceID string
+	if len(details) > 0 {
+		traceID = details[0]
+	}
+	return &withTraceID{cause: cause, traceID: traceID}
+}
+
+func init() {
+	errbase.RegisterWrapperDecoder(errbase.GetTypeKey((*withTraceID)(nil)), decodeWithTraceID)
+}
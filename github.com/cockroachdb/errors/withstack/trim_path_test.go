@@ -0,0 +1,47 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/errors/testutils"
+)
+
+// TestTrimPathWindows checks that Windows-style paths - which the
+// SrcDirs-derived prefixes never match - are normalized and trimmed
+// to the same module-relative form as on POSIX.
+func TestTrimPathWindows(t *testing.T) {
+	tt := testutils.T{T: t}
+
+	testData := []struct {
+		input    string
+		expected string
+	}{
+		// GOPATH layout.
+		{`C:\Users\dev\go\src\example.com\svc\server\handler.go`,
+			"example.com/svc/server/handler.go"},
+		// Module cache layout.
+		{`C:\Users\dev\go\pkg\mod\example.com\dep@v1.2.3\util.go`,
+			"example.com/dep@v1.2.3/util.go"},
+		// Neither marker: separators are still normalized.
+		{`D:\build\work\main.go`, "D:/build/work/main.go"},
+		// POSIX paths without a known prefix pass through untouched.
+		{"/opt/app/main.go", "/opt/app/main.go"},
+	}
+	for _, tc := range testData {
+		tt.CheckEqual(trimPath(tc.input), tc.expected)
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+// maxFunctionNameLength is set via SetMaxFunctionNameLength().
+var maxFunctionNameLength = 0
+
+// nameEllipsis replaces the middle of over-long names.
+const nameEllipsis = "..."
+
+// SetMaxFunctionNameLength bounds the length of the Function (and,
+// when needed, Module) strings stored in extracted frames. Names
+// longer than n are truncated with a middle ellipsis, preserving both
+// the head and the tail of the name — for function names these are
+// the most meaningful parts (package tail and method name), e.g. for
+// enormous generic instantiations.
+//
+// The default, 0, applies no limit.
+func SetMaxFunctionNameLength(n int) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	maxFunctionNameLength = n
+}
+
+// truncateName applies the configured name length limit.
+func truncateName(name string) string {
+	configMu.RLock()
+	n := maxFunctionNameLength
+	configMu.RUnlock()
+	if n <= 0 || len(name) <= n {
+		return name
+	}
+	if n <= len(nameEllipsis) {
+		return name[:n]
+	}
+	keep := n - len(nameEllipsis)
+	head := keep / 2
+	tail := keep - head
+	return name[:head] + nameEllipsis + name[len(name)-tail:]
+}
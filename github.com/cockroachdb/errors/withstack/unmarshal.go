@@ -0,0 +1,34 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package withstack
+
+import "encoding/json"
+
+// UnmarshalReportableStackTrace reconstructs a ReportableStackTrace
+// from its Sentry JSON representation, as previously produced by
+// marshaling the structure (e.g. by a Sentry transport or a
+// collector that stores raw events).
+//
+// This makes it possible to re-process stored stacks — re-filter,
+// re-collapse, re-fingerprint — without access to the original error
+// object: the post-processing helpers in this package operate
+// directly on the *ReportableStackTrace.
+func UnmarshalReportableStackTrace(data []byte) (*ReportableStackTrace, error) {
+	var st ReportableStackTrace
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
@@ -16,6 +16,8 @@ package withstack
 
 import (
 	"fmt"
+	"runtime"
+	"time"
 
 	"github.com/cockroachdb/errors/errbase"
 )
@@ -44,15 +46,47 @@ func WithStack(err error) error {
 // of WithStackDepth itself.
 // See the documentation of WithStack() for more details.
 func WithStackDepth(err error, depth int) error {
+	// Skip the frame of WithStackDepth itself.
+	return WithStackDepthLimit(err, depth+1, 0)
+}
+
+// WithStackDepthLimit is like WithStackDepth but it also limits the
+// number of captured frames: at most maxFrames frames are retained,
+// starting from the given call depth. A maxFrames of zero or less
+// does not limit the capture. Use this for deeply recursive code
+// where full stack traces would produce oversized reports.
+// See the documentation of WithStack() for more details.
+func WithStackDepthLimit(err error, depth, maxFrames int) error {
 	if err == nil {
 		return nil
 	}
-	return &withStack{cause: err, stack: callers(depth + 1)}
+	w := &withStack{cause: err, skip: depth, stack: callersLimit(depth+1, maxFrames)}
+	if getCaptureTimestamps() {
+		w.captureTime = time.Now()
+	}
+	if getCaptureRuntimeStats() {
+		w.runtimeStats = snapshotRuntimeStats()
+	}
+	return w
 }
 
 type withStack struct {
 	cause error
 
+	// captureTime, if non-zero, is the time at which the stack trace
+	// was captured. See CaptureTimestamps().
+	captureTime time.Time
+
+	// skip is the call depth requested at capture time, i.e. the
+	// number of frames between the WithStackDepthLimit call and the
+	// frame considered the top of the captured stack. See
+	// GetCaptureSkip().
+	skip int
+
+	// runtimeStats, if non-nil, is a snapshot of runtime statistics
+	// taken at capture time. See CaptureRuntimeStats().
+	runtimeStats map[string]uint64
+
 	*stack
 }
 
@@ -78,5 +112,11 @@ func (w *withStack) FormatError(p errbase.Printer) error {
 }
 
 func (w *withStack) SafeDetails() []string {
-	return []string{fmt.Sprintf("%+v", w.StackTrace())}
+	// The payload is prefixed with the Go version that produced it,
+	// because the printed stack format has changed across Go releases
+	// (e.g. between go 1.11 and 1.12). This makes the flattened format
+	// self-describing so that parsePrintedStack() can select the
+	// proper parsing rules.
+	return []string{fmt.Sprintf("%s%s\n%+v",
+		printedStackGoVersionPrefix, runtime.Version(), w.StackTrace())}
 }
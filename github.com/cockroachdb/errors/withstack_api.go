@@ -34,3 +34,8 @@ func GetOneLineSource(err error) (file string, line int, fn string, ok bool) {
 func GetReportableStackTrace(err error) *ReportableStackTrace {
 	return withstack.GetReportableStackTrace(err)
 }
+
+// AsReportableStackTrace forwards a definition.
+func AsReportableStackTrace(err error) (*ReportableStackTrace, bool) {
+	return withstack.AsReportableStackTrace(err)
+}